@@ -2,16 +2,32 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/sqot0/packsmith/backend/internal/config"
 	"github.com/sqot0/packsmith/backend/internal/discord"
 	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/sources"
 )
 
 type App struct {
-	ctx         context.Context
-	ProjectPath string
+	ctx context.Context
+
+	// settings is replaced as a whole by the methods that change it, which
+	// are serialized by settingsMu. options are the saved options settings
+	// was built from, kept in optionsFile.
+	settings    atomic.Pointer[sources.Settings]
+	settingsMu  sync.Mutex
+	options     sources.Options
+	optionsFile string
+
+	// mu guards session, which is nil until a project is opened.
+	mu      sync.Mutex
+	session *session
 }
 
 func NewApp() *App {
@@ -23,6 +39,118 @@ func (a *App) Startup(ctx context.Context) {
 	logger.Init()
 	discord.Init()
 	logger.Log.Println("Logger initialized successfully")
+	a.loadSourceOptions()
+}
+
+// loadSourceOptions builds the source settings from the saved options,
+// falling back to the defaults when they cannot be read or used.
+func (a *App) loadSourceOptions() {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	file, err := sources.DefaultOptionsFile()
+	if err != nil {
+		logger.Log.Printf("Source options will not be saved: %v", err)
+	}
+	a.optionsFile = file
+
+	var opts sources.Options
+	if file != "" {
+		if opts, err = sources.LoadOptions(file); err != nil {
+			logger.Log.Printf("Using default source options: %v", err)
+			opts = sources.Options{}
+		}
+	}
+	settings, err := newSourceSettings(opts)
+	if err != nil {
+		logger.Log.Printf("Using default source options: %v", err)
+		opts = sources.Options{}
+		settings, _ = newSourceSettings(opts)
+	}
+	a.options = opts
+	a.settings.Store(settings)
+}
+
+// newSourceSettings builds settings from options, taking the CurseForge API
+// key from the CURSEFORGE_API_KEY environment variable when none is set.
+func newSourceSettings(opts sources.Options) (*sources.Settings, error) {
+	if opts.CurseForgeAPIKey == "" {
+		opts.CurseForgeAPIKey = os.Getenv("CURSEFORGE_API_KEY")
+	}
+	return sources.NewSettings(opts)
+}
+
+// applySourceOptions saves options and switches to settings built from them.
+// The caller holds settingsMu.
+func (a *App) applySourceOptions(opts sources.Options) error {
+	settings, err := newSourceSettings(opts)
+	if err != nil {
+		logger.Log.Printf("Error configuring sources: %v", err)
+		return err
+	}
+	if a.optionsFile != "" {
+		if err := sources.SaveOptions(a.optionsFile, opts); err != nil {
+			return err
+		}
+	}
+	a.options = opts
+	a.settings.Store(settings)
+	return nil
+}
+
+// Shutdown closes the open project, releasing its lock.
+func (a *App) Shutdown(ctx context.Context) {
+	a.mu.Lock()
+	s := a.session
+	a.session = nil
+	a.mu.Unlock()
+	if s != nil {
+		s.close()
+	}
+}
+
+// SetCurseForgeAPIKey saves the CurseForge API key. An empty key removes
+// it, falling back to CURSEFORGE_API_KEY.
+func (a *App) SetCurseForgeAPIKey(apiKey string) error {
+	logger.Log.Println("Setting CurseForge API key")
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	opts := a.options
+	opts.CurseForgeAPIKey = strings.TrimSpace(apiKey)
+	return a.applySourceOptions(opts)
+}
+
+// GetSourceOptions returns the saved source options.
+func (a *App) GetSourceOptions() sources.Options {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	return a.options
+}
+
+// ConfigureSources saves the source options and applies them. An empty
+// CurseForge API key keeps the saved one, use SetCurseForgeAPIKey to remove
+// it.
+func (a *App) ConfigureSources(opts sources.Options) error {
+	logger.Log.Printf("Configuring sources: modrinth=%q curseforge=%q curseforgeAPI=%q github=%q maven=%v proxy=%q",
+		opts.ModrinthURL, opts.CurseForgeURL, opts.CurseForgeAPIURL, opts.GitHubAPIURL, opts.MavenRepos, opts.Proxy)
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	if opts.CurseForgeAPIKey == "" {
+		opts.CurseForgeAPIKey = a.options.CurseForgeAPIKey
+	}
+	return a.applySourceOptions(opts)
+}
+
+func (a *App) ClearSourceCache() error {
+	logger.Log.Println("Clearing source cache")
+	settings := a.sourceSettings()
+	if settings.Cache == nil {
+		return nil
+	}
+	if err := settings.Cache.Clear(); err != nil {
+		logger.Log.Printf("Error clearing source cache: %v", err)
+		return err
+	}
+	return nil
 }
 
 func (a *App) GetLogs() (string, error) {
@@ -34,11 +162,47 @@ func (a *App) GetLogs() (string, error) {
 	return string(data), nil
 }
 
+// sourceSettings returns the current settings of the mod sources.
+func (a *App) sourceSettings() *sources.Settings {
+	return a.settings.Load()
+}
+
+var errNoProject = errors.New("initialize project before using other commands")
+
+func (a *App) currentSession() *session {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.session
+}
+
+// loadConfig returns a snapshot of the open project's config. Changes to it
+// are not saved, use update to change the config.
 func (a *App) loadConfig() (*config.Config, error) {
-	cfg, err := config.Load(a.ProjectPath)
-	if err != nil {
-		logger.Log.Printf("Error loading config: %v", err)
-		return nil, err
+	s := a.currentSession()
+	if s == nil {
+		logger.Log.Println("Error loading config: no project is open")
+		return nil, errNoProject
+	}
+	return s.snapshot(), nil
+}
+
+// update runs fn as a transaction on the open project's config. See
+// session.update.
+func (a *App) update(fn func(cfg *config.Config) error) error {
+	s := a.currentSession()
+	if s == nil {
+		logger.Log.Println("Error updating config: no project is open")
+		return errNoProject
+	}
+	return s.update(fn)
+}
+
+// view runs fn on the open project's config. See session.view.
+func (a *App) view(fn func(cfg *config.Config) error) error {
+	s := a.currentSession()
+	if s == nil {
+		logger.Log.Println("Error reading config: no project is open")
+		return errNoProject
 	}
-	return cfg, nil
+	return s.view(fn)
 }
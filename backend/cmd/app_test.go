@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sqot0/packsmith/backend/internal/sources"
+)
+
+// newSourcesApp returns an app whose source options are saved in a
+// temporary user config folder.
+func newSourcesApp(t *testing.T) *App {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+	t.Setenv("CURSEFORGE_API_KEY", "")
+	a := NewApp()
+	a.loadSourceOptions()
+	return a
+}
+
+func TestConfigureSourcesKeepsAPIKey(t *testing.T) {
+	a := newSourcesApp(t)
+	if err := a.ConfigureSources(sources.Options{CurseForgeAPIKey: "key", DisableCache: true}); err != nil {
+		t.Fatalf("ConfigureSources() error = %v", err)
+	}
+
+	if err := a.ConfigureSources(sources.Options{ModrinthURL: "http://localhost/v2", DisableCache: true}); err != nil {
+		t.Fatalf("ConfigureSources() error = %v", err)
+	}
+	s := a.sourceSettings()
+	if s.CurseForgeAPIKey != "key" || s.ModrinthURL != "http://localhost/v2" {
+		t.Errorf("settings = key %q, modrinth %q, want the key kept and the new URL", s.CurseForgeAPIKey, s.ModrinthURL)
+	}
+
+	if err := a.SetCurseForgeAPIKey(""); err != nil {
+		t.Fatalf("SetCurseForgeAPIKey() error = %v", err)
+	}
+	if got := a.sourceSettings().CurseForgeAPIKey; got != "" {
+		t.Errorf("CurseForgeAPIKey after removing it = %q", got)
+	}
+}
+
+func TestSourceOptionsSaved(t *testing.T) {
+	a := newSourcesApp(t)
+	opts := sources.Options{ModrinthURL: "http://localhost/v2", MavenRepos: []string{"http://localhost/maven"}, DisableCache: true}
+	if err := a.ConfigureSources(opts); err != nil {
+		t.Fatalf("ConfigureSources() error = %v", err)
+	}
+	if err := a.SetCurseForgeAPIKey(" key "); err != nil {
+		t.Fatalf("SetCurseForgeAPIKey() error = %v", err)
+	}
+
+	// A restarted app loads the saved options.
+	restarted := NewApp()
+	restarted.loadSourceOptions()
+	if got := restarted.GetSourceOptions(); got.ModrinthURL != opts.ModrinthURL || got.CurseForgeAPIKey != "key" {
+		t.Errorf("GetSourceOptions() after restart = %+v", got)
+	}
+	s := restarted.sourceSettings()
+	if s.ModrinthURL != "http://localhost/v2" || s.CurseForgeAPIKey != "key" || s.Cache != nil {
+		t.Errorf("settings after restart = %+v", s)
+	}
+
+	// Invalid options are neither applied nor saved.
+	if err := a.ConfigureSources(sources.Options{Proxy: "://bad", DisableCache: true}); err == nil {
+		t.Error("ConfigureSources() accepted an invalid proxy")
+	}
+	if got := a.GetSourceOptions(); got.ModrinthURL != opts.ModrinthURL {
+		t.Errorf("GetSourceOptions() after a failed change = %+v", got)
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/fs"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func (a *App) SelectModFile() (string, error) {
+	logger.Log.Println("Opening mod file selection dialog")
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Add Local Mod",
+		Filters: []runtime.FileFilter{{DisplayName: "Mod jars (*.jar)", Pattern: "*.jar"}},
+	})
+	if err != nil {
+		logger.Log.Printf("Error opening file dialog: %v", err)
+		return "", err
+	}
+	logger.Log.Printf("Selected mod file: %s", filePath)
+	return filePath, nil
+}
+
+// AddLocalMod stores a jar in the project cache and adds it as a local mod.
+// The mod is named after the jar.
+func (a *App) AddLocalMod(filePath, side string) (string, error) {
+	logger.Log.Printf("Adding local mod: %s", filePath)
+	if !strings.EqualFold(filepath.Ext(filePath), ".jar") {
+		logger.Log.Printf("Not a jar file: %s", filePath)
+		return "", fmt.Errorf("%s is not a jar file", filepath.Base(filePath))
+	}
+	modID := modIDFromFilename(filepath.Base(filePath))
+	err := a.update(func(cfg *config.Config) error {
+		if _, exists := cfg.Mods[modID]; exists {
+			logger.Log.Printf("Mod %s already in config", modID)
+			return fmt.Errorf("mod %s is already in the project", modID)
+		}
+		if err := cfg.CheckCacheName(filepath.Base(filePath)); err != nil {
+			return err
+		}
+
+		hash, err := fs.SHA256(filePath)
+		if err != nil {
+			return err
+		}
+		filename, err := fs.CopyToCache(cfg.ProjectPath(), filePath)
+		if err != nil {
+			logger.Log.Printf("Error copying mod to cache: %v", err)
+			return err
+		}
+
+		cfg.Mods[modID] = config.Mod{
+			Platform: config.PlatformLocal,
+			Side:     side,
+			Filename: filename,
+			Hash:     hash,
+		}
+		logger.Log.Printf("Local mod added to config: %s", modID)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Printf("Failed to add local mod: %v", err)
+		return "", err
+	}
+	return modID, nil
+}
+
+// AddURLMod downloads a jar from a direct link and adds it as a URL mod.
+// When expectedHash is given the jar must have that SHA-256 hash. The hash
+// of the jar is recorded and checked whenever it is downloaded again.
+func (a *App) AddURLMod(fileURL, expectedHash, side string) (string, error) {
+	logger.Log.Printf("Adding URL mod: %s", fileURL)
+	expectedHash = strings.ToLower(strings.TrimSpace(expectedHash))
+	var modID string
+	err := a.update(func(cfg *config.Config) error {
+		tmp, file, err := fs.DownloadTemp(a.sourceSettings().HTTPClient(), cfg.ProjectPath(), fileURL, "")
+		if err != nil {
+			logger.Log.Printf("Error downloading mod: %v", err)
+			return err
+		}
+		// The download only replaces the cached file once it is known to
+		// belong to no other mod.
+		defer os.Remove(tmp)
+
+		filename, hash := file.Name, file.SHA256
+		modID = modIDFromFilename(filename)
+		if _, exists := cfg.Mods[modID]; exists {
+			logger.Log.Printf("Mod %s already in config", modID)
+			return fmt.Errorf("mod %s is already in the project", modID)
+		}
+		if err := cfg.CheckCacheName(filename); err != nil {
+			return err
+		}
+
+		if expectedHash != "" && hash != expectedHash {
+			logger.Log.Printf("Hash mismatch for %s: expected %s, got %s", fileURL, expectedHash, hash)
+			return fmt.Errorf("%s has SHA-256 %s, expected %s", filename, hash, expectedHash)
+		}
+		if err := fs.MoveToCache(cfg.ProjectPath(), tmp, filename); err != nil {
+			return err
+		}
+
+		cfg.Mods[modID] = config.Mod{
+			Platform: config.PlatformURL,
+			Source:   fileURL,
+			URL:      fileURL,
+			Side:     side,
+			Filename: filename,
+			Hash:     hash,
+			SHA1:     file.SHA1,
+		}
+		logger.Log.Printf("URL mod added to config: %s", modID)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Printf("Failed to add URL mod: %v", err)
+		return "", err
+	}
+	return modID, nil
+}
+
+func modIDFromFilename(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename))
+}
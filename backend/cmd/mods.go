@@ -1,156 +1,259 @@
 package cmd
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+
 	"github.com/sqot0/packsmith/backend/internal/config"
 	"github.com/sqot0/packsmith/backend/internal/fs"
+	"github.com/sqot0/packsmith/backend/internal/importer"
 	"github.com/sqot0/packsmith/backend/internal/installer"
 	"github.com/sqot0/packsmith/backend/internal/logger"
 	"github.com/sqot0/packsmith/backend/internal/sources"
 	"github.com/sqot0/packsmith/backend/internal/updater"
 )
 
-func (a *App) SearchMods(query string, platform string) ([]sources.ModSearch, error) {
-	logger.Log.Printf("Searching mods with query: %s on platform: %s", query, platform)
+func (a *App) GetPlatforms() []string {
+	platforms := sources.Providers()
+	logger.Log.Printf("Available platforms: %v", platforms)
+	return platforms
+}
+
+func (a *App) SearchMods(query string, platform string, opts sources.SearchOptions) (*sources.SearchResult, error) {
+	logger.Log.Printf("Searching mods with query: %s on platform: %s, page: %d", query, platform, opts.Page)
 	cfg, err := a.loadConfig()
 	if err != nil {
 		logger.Log.Printf("Failed to load config for SearchMods: %v", err)
 		return nil, err
 	}
 
-	results, err := sources.SearchMods(cfg, query, platform)
+	result, err := sources.SearchMods(a.sourceSettings(), cfg, query, platform, opts)
 	if err != nil {
 		logger.Log.Printf("Error searching mods: %v", err)
 		return nil, err
 	}
-	logger.Log.Printf("Found %d mods matching query", len(results))
-	return results, nil
+	logger.Log.Printf("Found %d mods matching query, %d total", len(result.Hits), result.TotalHits)
+	return result, nil
 }
 
-func (a *App) AddMod(modID, platform string, metadata sources.ModMetaData) error {
-	logger.Log.Printf("Adding mod ID: %s from platform: %s", modID, platform)
+func (a *App) GetModDetails(modID, platform string) (*sources.ModDetails, error) {
+	logger.Log.Printf("Getting details for mod: %s on platform: %s", modID, platform)
 	cfg, err := a.loadConfig()
 	if err != nil {
-		logger.Log.Printf("Failed to load config for AddMod: %v", err)
-		return err
+		logger.Log.Printf("Failed to load config for GetModDetails: %v", err)
+		return nil, err
 	}
 
-	logger.Log.Printf("Getting download URL for mod: %s", modID)
-	url, err := sources.GetDownloadURL(cfg, modID, platform, metadata.Version)
+	details, err := sources.GetModDetails(a.sourceSettings(), cfg, modID, platform)
 	if err != nil {
-		logger.Log.Printf("Error getting download URL: %v", err)
-		return err
+		logger.Log.Printf("Error getting mod details: %v", err)
+		return nil, err
 	}
-	logger.Log.Printf("Download URL obtained: %s, version: %s", url, metadata.Version)
+	return details, nil
+}
 
-	logger.Log.Printf("Downloading mod file")
-	filename, err := fs.Download(a.ProjectPath, url, metadata.Version)
+// AddModResult reports what AddMod did. FallbackLoader is set when the added
+// version is for a loader the project loader accepts rather than the
+// project loader itself. Unresolved lists required dependencies that were
+// not added since no version of them could be found.
+type AddModResult struct {
+	ModID          string
+	Added          bool
+	FallbackLoader string
+	Dependencies   []sources.Dependency
+	Unresolved     []sources.Dependency
+	Conflicts      []sources.Conflict
+}
+
+func (a *App) AddMod(modID, platform string, metadata sources.ModMetaData) (*AddModResult, error) {
+	logger.Log.Printf("Adding mod ID: %s from platform: %s", modID, platform)
+	var result *AddModResult
+	err := a.update(func(cfg *config.Config) error {
+		logger.Log.Printf("Getting version for mod: %s", modID)
+		version, err := sources.FindVersion(a.sourceSettings(), cfg, modID, platform, metadata.VersionID, metadata.Version)
+		if err != nil {
+			logger.Log.Printf("Error getting version: %v", err)
+			return err
+		}
+		logger.Log.Printf("Download URL obtained: %s, version: %s", version.DownloadURL, version.Version)
+		if version.FallbackLoader != "" {
+			logger.Log.Printf("Version %s of %s is used through the %s loader", version.Version, modID, version.FallbackLoader)
+		}
+
+		deps, unresolved, err := sources.ResolveDependencies(a.sourceSettings(), cfg, modID, platform, version.ID)
+		if err != nil {
+			logger.Log.Printf("Error resolving dependencies: %v", err)
+			return err
+		}
+
+		candidate := cfg.Clone()
+		candidate.Mods[modID] = config.Mod{Platform: platform, ProjectID: version.ProjectID, Source: metadata.URL, Version: version.Version, VersionID: version.ID}
+		added := []string{modID}
+		for _, dep := range deps {
+			candidate.Mods[dep.ModID] = config.Mod{Platform: platform, ProjectID: dep.ProjectID, Source: dep.Source, Version: dep.Version, VersionID: dep.VersionID}
+			added = append(added, dep.ModID)
+		}
+		conflicts := sources.FindConflicts(a.sourceSettings(), candidate, added)
+		result = &AddModResult{ModID: modID, FallbackLoader: version.FallbackLoader, Dependencies: deps, Unresolved: unresolved, Conflicts: conflicts}
+		if len(conflicts) > 0 && !metadata.IgnoreConflicts {
+			logger.Log.Printf("Not adding mod %s, found %d conflicts", modID, len(conflicts))
+			return errRollback
+		}
+
+		logger.Log.Printf("Downloading mod file")
+		file, err := fs.Download(a.sourceSettings().HTTPClient(), cfg.ProjectPath(), version.DownloadURL, version.DownloadName())
+		if err != nil {
+			logger.Log.Printf("Error downloading mod: %v", err)
+			return err
+		}
+		logger.Log.Printf("Mod downloaded successfully, filename: %s", file.Name)
+
+		cfg.Mods[modID] = config.Mod{
+			Platform:  platform,
+			ProjectID: version.ProjectID,
+			Source:    metadata.URL,
+			URL:       version.DownloadURL,
+			Version:   version.Version,
+			VersionID: version.ID,
+			Side:      metadata.Side,
+			Filename:  file.Name,
+			SHA1:      file.SHA1,
+		}
+		logger.Log.Printf("Mod added to config: %s", modID)
+
+		if err := a.addDependencies(cfg, deps, platform, metadata.Side); err != nil {
+			return err
+		}
+		result.Added = true
+		return nil
+	})
 	if err != nil {
-		logger.Log.Printf("Error downloading mod: %v", err)
-		return err
-	}
-	logger.Log.Printf("Mod downloaded successfully, filename: %s", filename)
-
-	cfg.Mods[modID] = config.Mod{
-		Source:   metadata.URL,
-		URL:      url,
-		Version:  metadata.Version,
-		Side:     metadata.Side,
-		Filename: filename,
+		logger.Log.Printf("Failed to add mod %s: %v", modID, err)
+		return nil, err
 	}
-	logger.Log.Printf("Mod added to config: %s", modID)
+	return result, nil
+}
 
-	if err := config.Save(cfg); err != nil {
-		logger.Log.Printf("Error saving config: %v", err)
-		return err
+func (a *App) addDependencies(cfg *config.Config, deps []sources.Dependency, platform, side string) error {
+	for _, dep := range deps {
+		logger.Log.Printf("Downloading dependency: %s, version: %s", dep.ModID, dep.Version)
+		file, err := fs.Download(a.sourceSettings().HTTPClient(), cfg.ProjectPath(), dep.URL, dep.DownloadName())
+		if err != nil {
+			logger.Log.Printf("Error downloading dependency %s: %v", dep.ModID, err)
+			return fmt.Errorf("%s: %w", dep.ModID, err)
+		}
+		cfg.Mods[dep.ModID] = config.Mod{
+			Platform:   platform,
+			ProjectID:  dep.ProjectID,
+			Source:     dep.Source,
+			URL:        dep.URL,
+			Version:    dep.Version,
+			VersionID:  dep.VersionID,
+			Side:       side,
+			Filename:   file.Name,
+			SHA1:       file.SHA1,
+			Dependency: true,
+		}
+		logger.Log.Printf("Dependency added to config: %s (required by %s)", dep.ModID, dep.RequiredBy)
 	}
-	logger.Log.Println("Config saved successfully")
 	return nil
 }
 
 func (a *App) RemoveMod(modID string) error {
 	logger.Log.Printf("Removing mod ID: %s", modID)
-	cfg, err := a.loadConfig()
-	if err != nil {
-		logger.Log.Printf("Failed to load config for RemoveMod: %v", err)
-		return err
-	}
-
-	mod, ok := cfg.Mods[modID]
-	if !ok {
-		logger.Log.Printf("Mod %s not found in config", modID)
+	err := a.update(func(cfg *config.Config) error {
+		mod, ok := cfg.Mods[modID]
+		if !ok {
+			logger.Log.Printf("Mod %s not found in config", modID)
+			return errRollback
+		}
+
+		logger.Log.Printf("Deleting mod file: %s", mod.Filename)
+		if err := fs.Delete(cfg.ProjectPath(), mod.Filename); err != nil {
+			logger.Log.Printf("Error deleting mod file: %v", err)
+			return err
+		}
+
+		delete(cfg.Mods, modID)
+		logger.Log.Printf("Mod removed from config: %s", modID)
 		return nil
-	}
-
-	logger.Log.Printf("Deleting mod file: %s", mod.Filename)
-	if err := fs.Delete(a.ProjectPath, mod.Filename); err != nil {
-		logger.Log.Printf("Error deleting mod file: %v", err)
-		return err
-	}
-
-	delete(cfg.Mods, modID)
-	logger.Log.Printf("Mod removed from config: %s", modID)
-
-	if err := config.Save(cfg); err != nil {
-		logger.Log.Printf("Error saving config: %v", err)
+	})
+	if err != nil {
+		logger.Log.Printf("Failed to remove mod %s: %v", modID, err)
 		return err
 	}
-	logger.Log.Println("Config saved successfully")
 	return nil
 }
 
 func (a *App) ChangeModSide(modID, side string) error {
 	logger.Log.Printf("Changing side for mod ID: %s to: %s", modID, side)
-	cfg, err := a.loadConfig()
-	if err != nil {
-		logger.Log.Printf("Failed to load config for ChangeModSide: %v", err)
-		return err
-	}
-
-	mod, ok := cfg.Mods[modID]
-	if !ok {
-		logger.Log.Printf("Mod %s not found in config", modID)
+	err := a.update(func(cfg *config.Config) error {
+		mod, ok := cfg.Mods[modID]
+		if !ok {
+			logger.Log.Printf("Mod %s not found in config", modID)
+			return errRollback
+		}
+
+		mod.Side = side
+		cfg.Mods[modID] = mod
+		logger.Log.Printf("Mod side updated: %s", modID)
 		return nil
-	}
-
-	mod.Side = side
-	cfg.Mods[modID] = mod
-	logger.Log.Printf("Mod side updated: %s", modID)
-
-	if err := config.Save(cfg); err != nil {
-		logger.Log.Printf("Error saving config: %v", err)
+	})
+	if err != nil {
+		logger.Log.Printf("Failed to update mod %s: %v", modID, err)
 		return err
 	}
-	logger.Log.Println("Config saved successfully")
 	return nil
 }
 
 func (a *App) ChangeModLocked(modID string, lock bool) error {
 	logger.Log.Printf("Changing lock status for mod ID: %s to: %t", modID, lock)
-	cfg, err := a.loadConfig()
+	err := a.update(func(cfg *config.Config) error {
+		mod, ok := cfg.Mods[modID]
+		if !ok {
+			logger.Log.Printf("Mod %s not found in config", modID)
+			return errRollback
+		}
+
+		mod.Locked = lock
+		cfg.Mods[modID] = mod
+		logger.Log.Printf("Mod lock status updated: %s", modID)
+		return nil
+	})
 	if err != nil {
-		logger.Log.Printf("Failed to load config for ChangeModLocked: %v", err)
+		logger.Log.Printf("Failed to update mod %s: %v", modID, err)
 		return err
 	}
+	return nil
+}
 
-	mod, ok := cfg.Mods[modID]
-	if !ok {
-		logger.Log.Printf("Mod %s not found in config", modID)
-		return nil
+func (a *App) ChangeModChannel(modID, channel string) error {
+	logger.Log.Printf("Changing channel for mod ID: %s to: %s", modID, channel)
+	if channel != "" && !config.ValidChannel(channel) {
+		logger.Log.Printf("Invalid channel: %s", channel)
+		return fmt.Errorf("channel must be either 'release', 'beta', 'alpha' or empty for the project default")
 	}
-
-	mod.Locked = lock
-	cfg.Mods[modID] = mod
-	logger.Log.Printf("Mod lock status updated: %s", modID)
-
-	if err := config.Save(cfg); err != nil {
-		logger.Log.Printf("Error saving config: %v", err)
+	err := a.update(func(cfg *config.Config) error {
+		mod, ok := cfg.Mods[modID]
+		if !ok {
+			logger.Log.Printf("Mod %s not found in config", modID)
+			return errRollback
+		}
+
+		mod.Channel = channel
+		cfg.Mods[modID] = mod
+		logger.Log.Printf("Mod channel updated: %s", modID)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Printf("Failed to update mod %s: %v", modID, err)
 		return err
 	}
-	logger.Log.Println("Config saved successfully")
 	return nil
 }
 
-func (a *App) GetModVersions(modID string) ([]string, error) {
+func (a *App) GetModVersions(modID string) ([]sources.ModVersion, error) {
 	logger.Log.Printf("Getting versions for mod ID: %s", modID)
 	cfg, err := a.loadConfig()
 	if err != nil {
@@ -164,8 +267,7 @@ func (a *App) GetModVersions(modID string) ([]string, error) {
 		return nil, nil
 	}
 
-	platform := sources.GetModPlatform(mod.Source)
-	versions, err := sources.GetModVersions(cfg, modID, platform)
+	versions, err := sources.GetModVersions(a.sourceSettings(), cfg, modID, mod.Platform)
 	if err != nil {
 		logger.Log.Printf("Error getting mod versions: %v", err)
 		return nil, err
@@ -175,53 +277,81 @@ func (a *App) GetModVersions(modID string) ([]string, error) {
 	return versions, nil
 }
 
-func (a *App) ChangeModVersion(modID, version string) error {
-	logger.Log.Printf("Changing version for mod ID: %s to: %s", modID, version)
-	cfg, err := a.loadConfig()
-	if err != nil {
-		logger.Log.Printf("Failed to load config for ChangeModVersion: %v", err)
-		return err
-	}
-
-	mod, ok := cfg.Mods[modID]
-	if !ok {
-		logger.Log.Printf("Mod %s not found in config", modID)
+// ChangeModVersion switches a mod to another version and adds the required
+// dependencies of that version. It returns the added dependencies followed
+// by the required ones that could not be resolved, which have Unresolved
+// set.
+func (a *App) ChangeModVersion(modID, versionID string) ([]sources.Dependency, error) {
+	logger.Log.Printf("Changing version for mod ID: %s to: %s", modID, versionID)
+	var deps []sources.Dependency
+	err := a.update(func(cfg *config.Config) error {
+		mod, ok := cfg.Mods[modID]
+		if !ok {
+			logger.Log.Printf("Mod %s not found in config", modID)
+			return errRollback
+		}
+
+		version, err := sources.GetVersion(a.sourceSettings(), cfg, modID, mod.Platform, versionID)
+		if err != nil {
+			logger.Log.Printf("Error getting version: %v", err)
+			return err
+		}
+		logger.Log.Printf("Download URL obtained: %s, version: %s", version.DownloadURL, version.Version)
+		if version.FallbackLoader != "" {
+			logger.Log.Printf("Version %s of %s is used through the %s loader", version.Version, modID, version.FallbackLoader)
+		}
+
+		var unresolved []sources.Dependency
+		deps, unresolved, err = sources.ResolveDependencies(a.sourceSettings(), cfg, modID, mod.Platform, version.ID)
+		if err != nil {
+			logger.Log.Printf("Error resolving dependencies: %v", err)
+			return err
+		}
+
+		logger.Log.Printf("Downloading mod file")
+		file, err := fs.Download(a.sourceSettings().HTTPClient(), cfg.ProjectPath(), version.DownloadURL, version.DownloadName())
+		if err != nil {
+			logger.Log.Printf("Error downloading mod: %v", err)
+			return err
+		}
+
+		if version.ProjectID != "" {
+			mod.ProjectID = version.ProjectID
+		}
+		mod.Version = version.Version
+		mod.VersionID = version.ID
+		mod.URL = version.DownloadURL
+		mod.Filename = file.Name
+		mod.SHA1 = file.SHA1
+		cfg.Mods[modID] = mod
+		logger.Log.Printf("Mod version updated: %s", modID)
+
+		if err := a.addDependencies(cfg, deps, mod.Platform, mod.Side); err != nil {
+			return err
+		}
+		deps = append(deps, unresolved...)
 		return nil
-	}
-
-	url, err := sources.GetDownloadURL(cfg, modID, sources.GetModPlatform(mod.Source), version)
+	})
 	if err != nil {
-		logger.Log.Printf("Error getting download URL: %v", err)
-		return err
-	}
-	logger.Log.Printf("Download URL obtained: %s, version: %s", url, version)
-
-	logger.Log.Printf("Downloading mod file")
-	filename, err := fs.Download(a.ProjectPath, url, version)
-
-	mod.Version = version
-	mod.URL = url
-	mod.Filename = filename
-	cfg.Mods[modID] = mod
-	logger.Log.Printf("Mod version updated: %s", modID)
-
-	if err := config.Save(cfg); err != nil {
-		logger.Log.Printf("Error saving config: %v", err)
-		return err
+		logger.Log.Printf("Failed to change version of mod %s: %v", modID, err)
+		return nil, err
 	}
-
-	return nil
+	return deps, nil
 }
 
-func (a *App) CheckModsUpdates(modIDs []string) ([]updater.ModToUpdate, error) {
-	logger.Log.Printf("Checking updates for %d mods", len(modIDs))
+func (a *App) CheckModsUpdates(modIDs []string, forceRefresh bool) ([]updater.ModToUpdate, error) {
+	logger.Log.Printf("Checking updates for %d mods, force refresh: %t", len(modIDs), forceRefresh)
 	cfg, err := a.loadConfig()
 	if err != nil {
 		logger.Log.Printf("Failed to load config for CheckModsUpdates: %v", err)
 		return nil, err
 	}
 
-	modsToUpdate, err := updater.CheckMods(cfg, modIDs)
+	settings := a.sourceSettings()
+	if forceRefresh {
+		settings = settings.WithForceRefresh()
+	}
+	modsToUpdate, err := updater.CheckMods(settings, cfg, modIDs, cfg.ProjectPath())
 	if err != nil {
 		logger.Log.Printf("Error checking mods updates: %v", err)
 		return nil, err
@@ -230,25 +360,88 @@ func (a *App) CheckModsUpdates(modIDs []string) ([]updater.ModToUpdate, error) {
 	return modsToUpdate, nil
 }
 
-func (a *App) UpdateMods(modsToUpdate []updater.ModToUpdate) error {
+func (a *App) UpdateMods(modsToUpdate []updater.ModToUpdate, ignoreConflicts bool) ([]sources.Conflict, error) {
 	logger.Log.Printf("Updating %d mods", len(modsToUpdate))
+	var conflicts []sources.Conflict
+	var updateErr error
+	err := a.update(func(cfg *config.Config) error {
+		// The project may have changed since the updates were checked.
+		var pending []updater.ModToUpdate
+		for _, m := range modsToUpdate {
+			if mod, ok := cfg.Mods[m.ModId]; !ok || mod.Locked {
+				logger.Log.Printf("Skipping update of mod %s (removed or locked since the check)", m.ModId)
+				continue
+			}
+			pending = append(pending, m)
+		}
+
+		candidate := cfg.Clone()
+		modIDs := make([]string, 0, len(pending))
+		for _, m := range pending {
+			mod := candidate.Mods[m.ModId]
+			mod.Version = m.Version
+			mod.VersionID = m.VersionID
+			candidate.Mods[m.ModId] = mod
+			modIDs = append(modIDs, m.ModId)
+		}
+		conflicts = sources.FindConflicts(a.sourceSettings(), candidate, modIDs)
+		if len(conflicts) > 0 && !ignoreConflicts {
+			logger.Log.Printf("Not updating mods, found %d conflicts", len(conflicts))
+			return errRollback
+		}
+
+		// Mods that were updated are saved even when others failed, their
+		// old jars are already gone.
+		updateErr = updater.UpdateMods(a.sourceSettings(), cfg, pending, cfg.ProjectPath())
+		return nil
+	})
+	if err == nil {
+		err = updateErr
+	}
+	if err != nil {
+		logger.Log.Printf("Error updating mods: %v", err)
+		return conflicts, err
+	}
+	logger.Log.Println("Mods updated successfully")
+	return conflicts, nil
+}
+
+func (a *App) CheckCompatibility() ([]sources.Conflict, error) {
+	logger.Log.Println("Checking compatibility of project mods")
 	cfg, err := a.loadConfig()
 	if err != nil {
-		logger.Log.Printf("Failed to load config for UpdateMods: %v", err)
-		return err
+		logger.Log.Printf("Failed to load config for CheckCompatibility: %v", err)
+		return nil, err
 	}
 
-	if err := updater.UpdateMods(cfg, modsToUpdate, a.ProjectPath); err != nil {
-		logger.Log.Printf("Error updating mods: %v", err)
+	modIDs := slices.Collect(maps.Keys(cfg.Mods))
+	conflicts := sources.FindConflicts(a.sourceSettings(), cfg, modIDs)
+	logger.Log.Printf("Found %d conflicts", len(conflicts))
+	return conflicts, nil
+}
+
+func (a *App) ImportModsFolder(folderPath string) (*importer.Report, error) {
+	logger.Log.Printf("Importing mods folder: %s", folderPath)
+	var report *importer.Report
+	err := a.update(func(cfg *config.Config) error {
+		var err error
+		report, err = importer.ImportFolder(a.sourceSettings(), cfg, cfg.ProjectPath(), folderPath)
 		return err
+	})
+	if err != nil {
+		logger.Log.Printf("Error importing mods folder: %v", err)
+		return nil, err
 	}
-	logger.Log.Println("Mods updated successfully")
-	return nil
+	return report, nil
 }
 
+// InstallMods rebuilds the client and server folders of the open project.
+// Transactions wait until it is done, so the cache does not change under it.
 func (a *App) InstallMods() error {
 	logger.Log.Println("Installing mods")
-	err := installer.InstallMods(a.ProjectPath)
+	err := a.view(func(cfg *config.Config) error {
+		return installer.InstallMods(a.sourceSettings().HTTPClient(), cfg)
+	})
 	if err != nil {
 		logger.Log.Printf("Error installing mods: %v", err)
 		return err
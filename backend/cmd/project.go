@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"errors"
+
 	"github.com/sqot0/packsmith/backend/internal/config"
 	"github.com/sqot0/packsmith/backend/internal/discord"
 	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/sources"
+	"github.com/sqot0/packsmith/backend/internal/versions"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -21,25 +25,250 @@ func (a *App) SelectProjectDirectory() (string, error) {
 	return projectPath, nil
 }
 
+func (a *App) SelectModsFolder() (string, error) {
+	logger.Log.Println("Opening mods folder selection dialog")
+	folderPath, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Mods Folder",
+	})
+	if err != nil {
+		logger.Log.Printf("Error opening directory dialog: %v", err)
+		return "", err
+	}
+	logger.Log.Printf("Selected mods folder: %s", folderPath)
+	return folderPath, nil
+}
+
+// OpenProject opens the project at projectPath, locking it so no other
+// Packsmith instance can edit it. Opening the open project again reloads it
+// from disk.
 func (a *App) OpenProject(projectPath string) (*config.Config, error) {
 	logger.Log.Printf("Opening project at path: %s", projectPath)
-	cfg, err := config.Load(projectPath)
+	cfg, err := a.open(projectPath, config.Load)
 	if err != nil {
 		logger.Log.Printf("Error loading project config: %v", err)
 		return nil, err
 	}
-	a.ProjectPath = projectPath
+	return cfg, nil
+}
+
+// Project states reported by CheckProject.
+const (
+	ProjectOK            = "ok"
+	ProjectUninitialized = "uninitialized"
+	ProjectCorrupt       = "corrupt"
+	ProjectNewerSchema   = "newer"
+	ProjectLocked        = "locked"
+	ProjectUnreadable    = "unreadable"
+)
+
+// ProjectStatus tells why a project can or cannot be opened. Backup is the
+// newest config backup and is only set for corrupt projects, the only ones
+// that can be restored from a backup.
+type ProjectStatus struct {
+	State  string
+	Error  string
+	Backup *config.Backup
+}
+
+// CheckProject reports the state of the project at projectPath without
+// opening or changing it, so the frontend can react to a failed open.
+func (a *App) CheckProject(projectPath string) (*ProjectStatus, error) {
+	logger.Log.Printf("Checking project at path: %s", projectPath)
+	status := &ProjectStatus{State: ProjectOK}
+	err := a.withProjectLock(projectPath, func() error {
+		var corrupt *config.CorruptError
+		err := config.Check(projectPath)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, config.ErrUninitialized):
+			status.State = ProjectUninitialized
+		case errors.Is(err, config.ErrNewerSchema):
+			status.State = ProjectNewerSchema
+		case errors.As(err, &corrupt):
+			status.State = ProjectCorrupt
+			backup, err := config.LatestBackup(projectPath)
+			if err != nil {
+				logger.Log.Printf("Error listing config backups: %v", err)
+				return err
+			}
+			status.Backup = backup
+		default:
+			status.State = ProjectUnreadable
+		}
+		status.Error = err.Error()
+		return nil
+	})
+	if errors.Is(err, errProjectLocked) {
+		return &ProjectStatus{State: ProjectLocked, Error: err.Error()}, nil
+	}
+	if err != nil {
+		logger.Log.Printf("Error checking project: %v", err)
+		return nil, err
+	}
+	logger.Log.Printf("Project state: %s", status.State)
+	return status, nil
+}
+
+// RestoreProjectBackup replaces the project config with its newest backup
+// and opens the project.
+func (a *App) RestoreProjectBackup(projectPath string) (*config.Config, error) {
+	logger.Log.Printf("Restoring project from backup: %s", projectPath)
+	cfg, err := a.open(projectPath, config.RestoreLatestBackup)
+	if err != nil {
+		logger.Log.Printf("Error restoring project backup: %v", err)
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// open makes the project at projectPath the open project, with the config
+// returned by load. The project is locked before load runs and stays locked
+// until another project is opened or the app shuts down.
+func (a *App) open(projectPath string, load func(projectPath string) (*config.Config, error)) (*config.Config, error) {
+	s, err := a.openSession(projectPath, load)
+	if err != nil {
+		return nil, err
+	}
+	go a.backfillIDs(s)
+
+	cfg := s.snapshot()
 	discord.OpenProject(cfg)
 	logger.Log.Println("Project opened successfully")
 	return cfg, nil
 }
 
-func (a *App) InitializeProject(projectPath, name, mc, loader string) error {
-	logger.Log.Printf("Initializing project: %s with MC version: %s and with loader: %s", name, mc, loader)
-	if err := config.Init(projectPath, name, mc, loader); err != nil {
+func (a *App) openSession(projectPath string, load func(projectPath string) (*config.Config, error)) (*session, error) {
+	a.mu.Lock()
+	if s := a.session; s.isProject(projectPath) {
+		// Reloading waits for running transactions, which must not hold up
+		// every other call.
+		a.mu.Unlock()
+		if _, err := s.reload(load); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	lock, err := lockProject(projectPath)
+	if err != nil {
+		a.mu.Unlock()
+		return nil, err
+	}
+	cfg, err := load(projectPath)
+	if err != nil {
+		lock.Unlock()
+		a.mu.Unlock()
+		return nil, err
+	}
+	s := newSession(projectPath, lock, cfg)
+	old := a.session
+	a.session = s
+	a.mu.Unlock()
+
+	// Closing waits for transactions still running on the old project.
+	if old != nil {
+		old.close()
+	}
+	return s, nil
+}
+
+// backfillIDs looks up missing mod IDs in the background and records them
+// in their own transaction. The lookup runs on a snapshot, so mods changed
+// in the meantime are left alone.
+func (a *App) backfillIDs(s *session) {
+	before := s.snapshot()
+	changed := sources.BackfillIDs(a.sourceSettings(), before)
+	if len(changed) == 0 {
+		return
+	}
+	err := s.update(func(cfg *config.Config) error {
+		applied := 0
+		for modID, mod := range changed {
+			if current, ok := cfg.Mods[modID]; ok && current == before.Mods[modID] {
+				cfg.Mods[modID] = mod
+				applied++
+			}
+		}
+		if applied == 0 {
+			return errRollback
+		}
+		logger.Log.Printf("Saving config with backfilled IDs of %d mods", applied)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Printf("Error saving backfilled mod IDs: %v", err)
+	}
+}
+
+// withProjectLock runs fn while holding the lock of the project at
+// projectPath, waiting for running transactions when it is the open project.
+func (a *App) withProjectLock(projectPath string, fn func() error) error {
+	for {
+		if s := a.currentSession(); s.isProject(projectPath) {
+			err := s.view(func(*config.Config) error { return fn() })
+			// A session closed in the meantime no longer holds the lock.
+			if !errors.Is(err, errSessionClosed) {
+				return err
+			}
+			continue
+		}
+
+		a.mu.Lock()
+		if a.session.isProject(projectPath) {
+			a.mu.Unlock()
+			continue
+		}
+		defer a.mu.Unlock()
+		lock, err := lockProject(projectPath)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+		return fn()
+	}
+}
+
+// InitializeProject creates the project config after checking the versions
+// against the version catalogs. An empty loader version picks the newest
+// stable one.
+func (a *App) InitializeProject(projectPath, name, mc, loader, loaderVersion string) error {
+	logger.Log.Printf("Initializing project: %s with MC version: %s and with loader: %s %s", name, mc, loader, loaderVersion)
+	loaderVersion, err := versions.Resolve(a.sourceSettings(), mc, loader, loaderVersion)
+	if err != nil {
+		logger.Log.Printf("Error validating project versions: %v", err)
+		return err
+	}
+	err = a.withProjectLock(projectPath, func() error {
+		return config.Init(projectPath, name, mc, loader, loaderVersion)
+	})
+	if err != nil {
 		logger.Log.Printf("Error initializing project: %v", err)
 		return err
 	}
+	if s := a.currentSession(); s.isProject(projectPath) {
+		if _, err := s.reload(config.Load); err != nil {
+			return err
+		}
+	}
 	logger.Log.Println("Project initialized successfully")
 	return nil
 }
+
+func (a *App) ChangeProjectChannel(channel string) error {
+	logger.Log.Printf("Changing project channel to: %s", channel)
+	if !config.ValidChannel(channel) {
+		logger.Log.Printf("Invalid channel: %s", channel)
+		return errors.New("channel must be either 'release', 'beta' or 'alpha'")
+	}
+	err := a.update(func(cfg *config.Config) error {
+		cfg.Channel = channel
+		return nil
+	})
+	if err != nil {
+		logger.Log.Printf("Failed to change project channel: %v", err)
+		return err
+	}
+	logger.Log.Println("Project channel updated")
+	return nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/fs"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+const lockFileName = ".packsmith/lock"
+
+// errSessionClosed is returned for transactions on a project that has been
+// closed in the meantime.
+var errSessionClosed = errors.New("project was closed")
+
+// errProjectLocked is returned when another Packsmith instance has the
+// project open.
+var errProjectLocked = errors.New("project is already open in another Packsmith window")
+
+// errRollback ends a transaction without saving, for mutations that decide
+// not to change anything.
+var errRollback = errors.New("transaction rolled back")
+
+// session is the open project. It owns the only in-memory copy of the
+// config and holds the project lock file, so no other Packsmith instance
+// edits the project while it is open.
+//
+// Mutations run one at a time as transactions on a clone of the config,
+// which replaces the config once it has been saved. Reads take a snapshot
+// and never wait for a running transaction.
+type session struct {
+	path string
+	lock *fs.Lock
+
+	tx     sync.Mutex
+	mu     sync.RWMutex
+	cfg    *config.Config
+	closed bool
+}
+
+// lockProject takes the lock file of a project.
+func lockProject(projectPath string) (*fs.Lock, error) {
+	lock, err := fs.LockFile(filepath.Join(projectPath, lockFileName))
+	if errors.Is(err, fs.ErrLocked) {
+		logger.Log.Printf("Project is locked by another instance: %s", projectPath)
+		return nil, errProjectLocked
+	}
+	if err != nil {
+		logger.Log.Printf("Error locking project: %v", err)
+		return nil, err
+	}
+	return lock, nil
+}
+
+func newSession(projectPath string, lock *fs.Lock, cfg *config.Config) *session {
+	return &session{path: projectPath, lock: lock, cfg: cfg}
+}
+
+// isProject reports whether the session is for the project at projectPath.
+func (s *session) isProject(projectPath string) bool {
+	return s != nil && filepath.Clean(s.path) == filepath.Clean(projectPath)
+}
+
+// snapshot returns a copy of the current config.
+func (s *session) snapshot() *config.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Clone()
+}
+
+// update runs fn as a transaction. fn works on a copy of the config that is
+// saved and becomes the current config only when fn succeeds. Returning
+// errRollback discards the copy without reporting an error.
+func (s *session) update(fn func(cfg *config.Config) error) error {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+	if s.closed {
+		return errSessionClosed
+	}
+
+	cfg := s.snapshot()
+	if err := fn(cfg); err != nil {
+		if errors.Is(err, errRollback) {
+			logger.Log.Println("Transaction rolled back")
+			return nil
+		}
+		logger.Log.Printf("Transaction failed, discarding changes: %v", err)
+		return err
+	}
+
+	if err := config.Save(cfg); err != nil {
+		logger.Log.Printf("Error saving config: %v", err)
+		return err
+	}
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+	return nil
+}
+
+// view runs fn on a snapshot of the config while holding off transactions,
+// for work that reads the config and the project files but changes neither.
+func (s *session) view(fn func(cfg *config.Config) error) error {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+	if s.closed {
+		return errSessionClosed
+	}
+	return fn(s.snapshot())
+}
+
+// reload replaces the config with the one returned by load, waiting for
+// running transactions first.
+func (s *session) reload(load func(projectPath string) (*config.Config, error)) (*config.Config, error) {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+	if s.closed {
+		return nil, errSessionClosed
+	}
+
+	cfg, err := load(s.path)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+	return cfg.Clone(), nil
+}
+
+// close waits for running transactions and releases the project lock.
+func (s *session) close() {
+	s.tx.Lock()
+	defer s.tx.Unlock()
+	logger.Log.Printf("Closing project session: %s", s.path)
+	s.closed = true
+	if err := s.lock.Unlock(); err != nil {
+		logger.Log.Printf("Error releasing project lock: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+func newTestProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := config.Init(dir, "test", "1.20.1", "fabric", ""); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func openTestSession(t *testing.T, a *App, projectPath string) *session {
+	t.Helper()
+	s, err := a.openSession(projectPath, config.Load)
+	if err != nil {
+		t.Fatalf("openSession() error = %v", err)
+	}
+	t.Cleanup(func() { a.Shutdown(context.Background()) })
+	return s
+}
+
+func TestLockProject(t *testing.T) {
+	dir := newTestProject(t)
+	lock, err := lockProject(dir)
+	if err != nil {
+		t.Fatalf("lockProject() error = %v", err)
+	}
+	if _, err := lockProject(dir); !errors.Is(err, errProjectLocked) {
+		t.Errorf("second lockProject() error = %v, want errProjectLocked", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = lockProject(dir)
+	if err != nil {
+		t.Fatalf("lockProject() after unlock error = %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestSessionUpdate(t *testing.T) {
+	dir := newTestProject(t)
+	s := openTestSession(t, NewApp(), dir)
+	saved := func() *config.Config {
+		t.Helper()
+		cfg, err := config.Load(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	failed := errors.New("failed")
+	tests := []struct {
+		name    string
+		result  error
+		wantErr error
+		applied bool
+	}{
+		{name: "error discards changes", result: failed, wantErr: failed},
+		{name: "rollback discards changes silently", result: errRollback},
+		{name: "success saves changes", applied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.update(func(cfg *config.Config) error {
+				cfg.Mods[tt.name] = config.Mod{Platform: "modrinth"}
+				return tt.result
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("update() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, ok := s.snapshot().Mods[tt.name]; ok != tt.applied {
+				t.Errorf("mod in session config = %t, want %t", ok, tt.applied)
+			}
+			if _, ok := saved().Mods[tt.name]; ok != tt.applied {
+				t.Errorf("mod in saved config = %t, want %t", ok, tt.applied)
+			}
+		})
+	}
+
+	// Snapshots are copies, changing one does not affect the session.
+	snapshot := s.snapshot()
+	snapshot.Mods["changed"] = config.Mod{}
+	if _, ok := s.snapshot().Mods["changed"]; ok {
+		t.Error("changing a snapshot changed the session config")
+	}
+}
+
+func TestSessionLocksProject(t *testing.T) {
+	dir := newTestProject(t)
+	a := NewApp()
+	s := openTestSession(t, a, dir)
+
+	if _, err := NewApp().openSession(dir, config.Load); !errors.Is(err, errProjectLocked) {
+		t.Errorf("opening the project in another instance error = %v, want errProjectLocked", err)
+	}
+
+	// Reopening the same project keeps the session.
+	reopened, err := a.openSession(dir, config.Load)
+	if err != nil {
+		t.Fatalf("reopening the project error = %v", err)
+	}
+	if reopened != s {
+		t.Error("reopening the project replaced its session")
+	}
+
+	a.Shutdown(context.Background())
+	if err := s.update(func(*config.Config) error { return nil }); !errors.Is(err, errSessionClosed) {
+		t.Errorf("update() after shutdown error = %v, want errSessionClosed", err)
+	}
+	lock, err := lockProject(dir)
+	if err != nil {
+		t.Fatalf("project still locked after shutdown: %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestOpenDoesNotWaitForTransactions(t *testing.T) {
+	first, second := newTestProject(t), newTestProject(t)
+	a := NewApp()
+	old := openTestSession(t, a, first)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	txDone := make(chan error)
+	go func() {
+		txDone <- old.update(func(*config.Config) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	opened := make(chan error)
+	go func() {
+		_, err := a.openSession(second, config.Load)
+		opened <- err
+	}()
+
+	// The new project becomes current while the old transaction runs, and
+	// calls that need the app mutex do not wait for it.
+	deadline := time.After(5 * time.Second)
+	for !a.currentSession().isProject(second) {
+		select {
+		case <-deadline:
+			close(release)
+			t.Fatal("opening a project waited for a transaction on the old one")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if _, err := a.loadConfig(); err != nil {
+		t.Errorf("loadConfig() during the old transaction error = %v", err)
+	}
+
+	close(release)
+	if err := <-txDone; err != nil {
+		t.Errorf("old transaction error = %v", err)
+	}
+	if err := <-opened; err != nil {
+		t.Fatalf("openSession() error = %v", err)
+	}
+	if err := old.update(func(*config.Config) error { return nil }); !errors.Is(err, errSessionClosed) {
+		t.Errorf("update() on the old session error = %v, want errSessionClosed", err)
+	}
+}
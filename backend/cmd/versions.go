@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/versions"
+)
+
+func (a *App) ListMinecraftVersions(includeSnapshots bool) ([]versions.MinecraftVersion, error) {
+	logger.Log.Printf("Listing Minecraft versions, snapshots: %t", includeSnapshots)
+	list, err := versions.ListMinecraftVersions(a.sourceSettings(), includeSnapshots)
+	if err != nil {
+		logger.Log.Printf("Error listing Minecraft versions: %v", err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (a *App) ListLoaderVersions(loader, mc string) ([]versions.LoaderVersion, error) {
+	logger.Log.Printf("Listing %s versions for Minecraft %s", loader, mc)
+	list, err := versions.ListLoaderVersions(a.sourceSettings(), loader, mc)
+	if err != nil {
+		logger.Log.Printf("Error listing loader versions: %v", err)
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+const (
+	backupDir = ".packsmith/backups"
+	// MaxBackups is the number of backups of packsmith.json kept per project.
+	MaxBackups = 10
+
+	backupTimeFormat = "20060102-150405.000000"
+)
+
+// Backup is a timestamped copy of packsmith.json taken before it was
+// overwritten.
+type Backup struct {
+	Path    string
+	Created string
+}
+
+// CorruptError is returned by Load when packsmith.json exists but cannot be
+// read as a config, typically after a crash during a write.
+type CorruptError struct {
+	Path string
+	Err  error
+}
+
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("%s is corrupt: %v", e.Path, e.Err)
+}
+
+func (e *CorruptError) Unwrap() error { return e.Err }
+
+// writeFileAtomic writes data to a temp file next to file, fsyncs it and
+// renames it into place, so the file is never left partially written.
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp-*")
+	if err != nil {
+		logger.Log.Printf("Error creating temp file: %v", err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		logger.Log.Printf("Error writing temp file: %v", err)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		logger.Log.Printf("Error syncing temp file: %v", err)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		logger.Log.Printf("Error closing temp file: %v", err)
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		logger.Log.Printf("Error setting file mode: %v", err)
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		logger.Log.Printf("Error renaming temp file: %v", err)
+		return err
+	}
+
+	// Persist the rename itself. Directories cannot be synced on Windows,
+	// where the rename is durable once it returns.
+	if dir, err := os.Open(filepath.Dir(file)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// backupConfig copies the current packsmith.json into the backups folder
+// and removes the oldest backups beyond MaxBackups. A file that is not valid
+// JSON is not backed up, so a corrupt file never pushes out a good backup.
+func backupConfig(projectPath string) error {
+	data, err := os.ReadFile(path.Join(projectPath, "packsmith.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		logger.Log.Printf("Error reading config for backup: %v", err)
+		return err
+	}
+	if !json.Valid(data) {
+		logger.Log.Println("Not backing up corrupt config")
+		return nil
+	}
+
+	dir := path.Join(projectPath, backupDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		logger.Log.Printf("Error creating backup folder: %v", err)
+		return err
+	}
+	name := "packsmith-" + time.Now().UTC().Format(backupTimeFormat) + ".json"
+	if err := writeFileAtomic(path.Join(dir, name), data); err != nil {
+		logger.Log.Printf("Error writing config backup: %v", err)
+		return err
+	}
+
+	backups, err := ListBackups(projectPath)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups[min(len(backups), MaxBackups):] {
+		logger.Log.Printf("Removing old config backup: %s", b.Path)
+		if err := os.Remove(b.Path); err != nil {
+			logger.Log.Printf("Error removing old config backup: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListBackups returns the backups of a project, newest first.
+func ListBackups(projectPath string) ([]Backup, error) {
+	dir := path.Join(projectPath, backupDir)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Log.Printf("Error reading backup folder: %v", err)
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		stamp, ok := strings.CutPrefix(e.Name(), "packsmith-")
+		if !ok {
+			continue
+		}
+		created, err := time.Parse(backupTimeFormat, strings.TrimSuffix(stamp, ".json"))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Path: path.Join(dir, e.Name()), Created: created.Format(time.RFC3339)})
+	}
+	// Backup names hold a sortable timestamp.
+	slices.SortFunc(backups, func(a, b Backup) int { return strings.Compare(b.Path, a.Path) })
+	return backups, nil
+}
+
+// LatestBackup returns the newest backup of a project, or nil when there is
+// none.
+func LatestBackup(projectPath string) (*Backup, error) {
+	backups, err := ListBackups(projectPath)
+	if err != nil || len(backups) == 0 {
+		return nil, err
+	}
+	return &backups[0], nil
+}
+
+// RestoreLatestBackup replaces a corrupt packsmith.json with its newest
+// backup and loads it. The replaced file is kept as packsmith.json.corrupt.
+// A packsmith.json that is not corrupt is never replaced.
+func RestoreLatestBackup(projectPath string) (*Config, error) {
+	logger.Log.Printf("Restoring config backup for project: %s", projectPath)
+	var corrupt *CorruptError
+	if err := Check(projectPath); !errors.As(err, &corrupt) {
+		logger.Log.Printf("Not restoring backup, config is not corrupt: %v", err)
+		if err == nil {
+			return nil, errors.New("packsmith.json is not corrupt, refusing to replace it with a backup")
+		}
+		return nil, fmt.Errorf("refusing to replace packsmith.json with a backup: %w", err)
+	}
+
+	backup, err := LatestBackup(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if backup == nil {
+		logger.Log.Println("No config backup to restore")
+		return nil, errors.New("no backup of packsmith.json found")
+	}
+
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		logger.Log.Printf("Error reading config backup: %v", err)
+		return nil, err
+	}
+	cfgFile := path.Join(projectPath, "packsmith.json")
+	if err := os.Rename(cfgFile, cfgFile+".corrupt"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Log.Printf("Error keeping corrupt config: %v", err)
+		return nil, err
+	}
+	if err := writeFileAtomic(cfgFile, data); err != nil {
+		return nil, err
+	}
+	logger.Log.Printf("Restored config from backup: %s", backup.Path)
+	return Load(projectPath)
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		corrupt bool
+		wantErr error
+	}{
+		{name: "missing", wantErr: ErrUninitialized},
+		{name: "valid", data: `{"schemaVersion":1,"mods":{}}`},
+		{name: "newer", data: `{"schemaVersion":99}`, wantErr: ErrNewerSchema},
+		{name: "truncated", data: `{"schemaVersion":1,"mods":`, corrupt: true},
+		{name: "wrong type", data: `{"schemaVersion":1,"mods":[]}`, corrupt: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.data != "" {
+				if err := os.WriteFile(filepath.Join(dir, "packsmith.json"), []byte(tt.data), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := Check(dir)
+			var corrupt *CorruptError
+			if got := errors.As(err, &corrupt); got != tt.corrupt {
+				t.Errorf("Check() error = %v, corrupt = %t, want %t", err, got, tt.corrupt)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !tt.corrupt && err != nil {
+				t.Errorf("Check() error = %v", err)
+			}
+		})
+	}
+}
+
+// newProject initialises a project and loads its config.
+func newProject(t *testing.T) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	if err := Init(dir, "pack", "1.20.1", "fabric", ""); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "packsmith.json")
+	for _, data := range []string{"first", "second"} {
+		if err := writeFileAtomic(file, []byte(data)); err != nil {
+			t.Fatalf("writeFileAtomic() error = %v", err)
+		}
+		got, err := os.ReadFile(file)
+		if err != nil || string(got) != data {
+			t.Errorf("file = %q, %v, want %q", got, err, data)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("folder holds %d files, want no temp files left behind", len(entries))
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "packsmith.json"), []byte("x")); err == nil {
+		t.Error("writeFileAtomic() into a missing folder succeeded")
+	}
+}
+
+func TestBackupPruning(t *testing.T) {
+	cfg := newProject(t)
+	for i := range MaxBackups + 3 {
+		cfg.Name = strings.Repeat("x", i+1)
+		if err := Save(cfg); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	backups, err := ListBackups(cfg.ProjectPath())
+	if err != nil {
+		t.Fatalf("ListBackups() error = %v", err)
+	}
+	if len(backups) != MaxBackups {
+		t.Fatalf("kept %d backups, want %d", len(backups), MaxBackups)
+	}
+	// The newest backup holds the config as it was before the last save.
+	data, err := os.ReadFile(backups[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"name": "` + strings.Repeat("x", MaxBackups+2) + `"`; !strings.Contains(string(data), want) {
+		t.Errorf("newest backup = %s, want it to contain %s", data, want)
+	}
+	for i := 1; i < len(backups); i++ {
+		if backups[i-1].Path <= backups[i].Path {
+			t.Errorf("backups not listed newest first: %s before %s", backups[i-1].Path, backups[i].Path)
+		}
+	}
+}
+
+func TestCorruptConfigNotBackedUp(t *testing.T) {
+	cfg := newProject(t)
+	cfgFile := filepath.Join(cfg.ProjectPath(), "packsmith.json")
+	if err := os.WriteFile(cfgFile, []byte(`{"schemaVersion":1,"mo`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if backups, err := ListBackups(cfg.ProjectPath()); err != nil || len(backups) != 0 {
+		t.Errorf("ListBackups() = %v, %v, want the corrupt file not backed up", backups, err)
+	}
+}
+
+func TestRestoreLatestBackup(t *testing.T) {
+	cfg := newProject(t)
+	dir := cfg.ProjectPath()
+
+	if _, err := RestoreLatestBackup(dir); err == nil {
+		t.Error("RestoreLatestBackup() replaced a valid config")
+	}
+
+	cfgFile := filepath.Join(dir, "packsmith.json")
+	corrupt := []byte(`{"schemaVersion":1,"mo`)
+	if err := os.WriteFile(cfgFile, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreLatestBackup(dir); err == nil {
+		t.Error("RestoreLatestBackup() without backups succeeded")
+	}
+
+	// Without a file to back up the first save only writes packsmith.json,
+	// the second one backs it up.
+	if err := os.Remove(cfgFile); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Name = "first"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Name = "second"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfgFile, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := RestoreLatestBackup(dir)
+	if err != nil {
+		t.Fatalf("RestoreLatestBackup() error = %v", err)
+	}
+	if restored.Name != "first" {
+		t.Errorf("restored config name = %q, want the newest backup", restored.Name)
+	}
+	kept, err := os.ReadFile(cfgFile + ".corrupt")
+	if err != nil || string(kept) != string(corrupt) {
+		t.Errorf("packsmith.json.corrupt = %q, %v, want the replaced file", kept, err)
+	}
+	if err := Check(dir); err != nil {
+		t.Errorf("Check() after restore error = %v", err)
+	}
+}
@@ -3,38 +3,137 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/sqot0/packsmith/backend/internal/logger"
 )
 
+// Release channel policies. A policy allows its own channel and every more
+// stable one, so ChannelBeta allows beta and release versions.
+const (
+	ChannelRelease = "release"
+	ChannelBeta    = "beta"
+	ChannelAlpha   = "alpha"
+)
+
+// Platforms of mods that are not provided by a mod platform. Local mods are
+// jars stored in the project cache, URL mods are downloaded from a direct
+// link and checked against their recorded hash.
+const (
+	PlatformLocal = "local"
+	PlatformURL   = "url"
+)
+
 type Mod struct {
-	Source   string `json:"source"`
-	Side     string `json:"side"`
-	Version  string `json:"version"`
-	URL      string `json:"url"`
-	Filename string `json:"filename"`
-	Locked   bool   `json:"locked"`
+	Platform   string `json:"platform"`
+	ProjectID  string `json:"projectId"`
+	Source     string `json:"source"`
+	Side       string `json:"side"`
+	Version    string `json:"version"`
+	VersionID  string `json:"versionId"`
+	URL        string `json:"url"`
+	Filename   string `json:"filename"`
+	Locked     bool   `json:"locked"`
+	Dependency bool   `json:"dependency"`
+	Channel    string `json:"channel"`
+	Hash       string `json:"hash,omitempty"`
+	// SHA1 is the SHA-1 digest of the cached jar, recorded when it is
+	// downloaded so update checks do not have to hash the cache.
+	SHA1 string `json:"sha1,omitempty"`
+	// Unresolved is set when the stored version of a mod added before IDs
+	// were recorded matched no version on its platform, so the IDs are not
+	// looked up again.
+	Unresolved bool `json:"unresolved,omitempty"`
 }
 
 type Config struct {
-	Name      string         `json:"name"`
-	Minecraft string         `json:"minecraft"`
-	Loader    string         `json:"loader"`
-	Mods      map[string]Mod `json:"mods"`
-	path      string
+	SchemaVersion int            `json:"schemaVersion"`
+	Name          string         `json:"name"`
+	Minecraft     string         `json:"minecraft"`
+	Loader        string         `json:"loader"`
+	LoaderVersion string         `json:"loaderVersion,omitempty"`
+	Channel       string         `json:"channel"`
+	Mods          map[string]Mod `json:"mods"`
+	path          string
+}
+
+// ValidChannel reports whether channel is a known release channel policy.
+func ValidChannel(channel string) bool {
+	return channel == ChannelRelease || channel == ChannelBeta || channel == ChannelAlpha
+}
+
+// ModChannel returns the channel policy of a mod, falling back to the
+// project default and then to release only.
+func (c *Config) ModChannel(mod Mod) string {
+	if ValidChannel(mod.Channel) {
+		return mod.Channel
+	}
+	if ValidChannel(c.Channel) {
+		return c.Channel
+	}
+	return ChannelRelease
+}
+
+// platformFromSource guesses the platform of mods saved before it was
+// recorded. Sources from unknown hosts are left without a platform.
+func platformFromSource(source string) string {
+	switch {
+	case strings.HasPrefix(source, "https://www.curseforge.com/"):
+		return "curseforge"
+	case strings.HasPrefix(source, "https://modrinth.com/"):
+		return "modrinth"
+	}
+	return ""
+}
+
+// ProjectPath returns the folder of the project the config belongs to.
+func (c *Config) ProjectPath() string {
+	return c.path
+}
+
+// Clone returns a copy of the config whose mods can be changed without
+// affecting the original.
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.Mods = maps.Clone(c.Mods)
+	return &clone
+}
+
+// CheckCacheName fails when another mod already uses name in the project
+// cache, where adding a mod of the same file name would replace its jar.
+// Names are compared ignoring case, as they are on Windows and macOS.
+func (c *Config) CheckCacheName(name string) error {
+	for id, mod := range c.Mods {
+		if strings.EqualFold(mod.Filename, name) {
+			logger.Log.Printf("Cache file %s already belongs to mod %s", name, id)
+			return fmt.Errorf("mod %s already uses a file named %s", id, name)
+		}
+	}
+	return nil
 }
 
-func Init(projectPath, name, mc, loader string) error {
-	logger.Log.Printf("Initializing config for project: %s, MC: %s, Loader: %s", name, mc, loader)
+func Init(projectPath, name, mc, loader, loaderVersion string) error {
+	logger.Log.Printf("Initializing config for project: %s, MC: %s, Loader: %s %s", name, mc, loader, loaderVersion)
 
 	if loader != "forge" && loader != "fabric" && loader != "neoforge" && loader != "quilt" {
 		logger.Log.Printf("Invalid loader specified: %s", loader)
 		return errors.New("loader must be either 'forge', 'neoforge', 'quilt' or 'fabric'")
 	}
 
-	cfg := Config{name, mc, loader, map[string]Mod{}, projectPath}
+	cfg := Config{
+		SchemaVersion: SchemaVersion,
+		Name:          name,
+		Minecraft:     mc,
+		Loader:        loader,
+		LoaderVersion: loaderVersion,
+		Channel:       ChannelRelease,
+		Mods:          map[string]Mod{},
+		path:          projectPath,
+	}
 	err := write(cfg)
 	if err != nil {
 		logger.Log.Printf("Error writing initial config: %v", err)
@@ -44,24 +143,67 @@ func Init(projectPath, name, mc, loader string) error {
 	return nil
 }
 
+// ErrUninitialized is returned by Load when the project has no
+// packsmith.json.
+var ErrUninitialized = errors.New("initialize project before using other commands")
+
 func Load(projectPath string) (*Config, error) {
 	logger.Log.Printf("Loading config from path: %s", projectPath)
+	cfg, data, version, err := read(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if version < SchemaVersion {
+		if err := backupOriginal(projectPath, data, version); err != nil {
+			return nil, err
+		}
+		if err := write(*cfg); err != nil {
+			logger.Log.Printf("Error writing migrated config: %v", err)
+			return nil, err
+		}
+		logger.Log.Printf("Config migrated from schema version %d to %d", version, SchemaVersion)
+	}
+	logger.Log.Println("Config loaded successfully")
+	return cfg, nil
+}
+
+// Check reports the error Load would return for a project, without
+// migrating or writing anything.
+func Check(projectPath string) error {
+	logger.Log.Printf("Checking config at path: %s", projectPath)
+	_, _, _, err := read(projectPath)
+	return err
+}
+
+// read reads and migrates packsmith.json. It also returns the file as read
+// and the schema version it had.
+func read(projectPath string) (*Config, []byte, int, error) {
 	cfgFile := path.Join(projectPath, "packsmith.json")
 	data, err := os.ReadFile(cfgFile)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Log.Printf("Config file not found: %s", cfgFile)
+		return nil, nil, 0, ErrUninitialized
+	}
 	if err != nil {
 		logger.Log.Printf("Error reading config file: %v", err)
-		return nil, errors.New("initialize project before using other commands")
+		return nil, nil, 0, err
 	}
 
-	var cfg Config
-	cfg.path = projectPath
-	err = json.Unmarshal(data, &cfg)
+	migrated, version, err := migrate(data)
+	if errors.Is(err, ErrNewerSchema) {
+		return nil, nil, version, err
+	}
 	if err != nil {
+		return nil, nil, version, &CorruptError{Path: cfgFile, Err: err}
+	}
+
+	cfg := Config{path: projectPath}
+	if err := json.Unmarshal(migrated, &cfg); err != nil {
 		logger.Log.Printf("Error unmarshaling config: %v", err)
-		return nil, err
+		return nil, nil, version, &CorruptError{Path: cfgFile, Err: err}
 	}
-	logger.Log.Println("Config loaded successfully")
-	return &cfg, nil
+	return &cfg, data, version, nil
 }
 
 func Save(cfg *Config) error {
@@ -77,13 +219,18 @@ func Save(cfg *Config) error {
 
 func write(cfg Config) error {
 	logger.Log.Printf("Writing config to file: %s", path.Join(cfg.path, "packsmith.json"))
+	cfg.SchemaVersion = SchemaVersion
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		logger.Log.Printf("Error marshaling config: %v", err)
 		return err
 	}
+	if err := backupConfig(cfg.path); err != nil {
+		logger.Log.Printf("Error backing up config: %v", err)
+		return err
+	}
 	cfgFile := path.Join(cfg.path, "packsmith.json")
-	err = os.WriteFile(cfgFile, data, 0o644)
+	err = writeFileAtomic(cfgFile, data)
 	if err != nil {
 		logger.Log.Printf("Error writing config file: %v", err)
 		return err
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+// SchemaVersion is the version of the packsmith.json format written by this
+// version of Packsmith. Files without a schemaVersion are version 0.
+const SchemaVersion = 1
+
+// ErrNewerSchema is returned for files written by a newer Packsmith.
+var ErrNewerSchema = errors.New("packsmith.json was written by a newer version of Packsmith")
+
+// migration upgrades a raw config document from the version before it to
+// the next one.
+type migration func(doc map[string]any) error
+
+// migrations[i] upgrades a document from version i to version i+1.
+var migrations = []migration{
+	migrateRecordPlatforms,
+}
+
+// migrateRecordPlatforms records the platform of every mod, which used to be
+// guessed from its source URL, and fills in fields older files may lack.
+func migrateRecordPlatforms(doc map[string]any) error {
+	if _, ok := doc["channel"].(string); !ok {
+		doc["channel"] = ChannelRelease
+	}
+	mods, ok := doc["mods"].(map[string]any)
+	if !ok {
+		doc["mods"] = map[string]any{}
+		return nil
+	}
+	for id, raw := range mods {
+		mod, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("mod %s is not an object", id)
+		}
+		if platform, _ := mod["platform"].(string); platform != "" {
+			continue
+		}
+		source, _ := mod["source"].(string)
+		if platform := platformFromSource(source); platform != "" {
+			logger.Log.Printf("Recorded platform %s for mod: %s", platform, id)
+			mod["platform"] = platform
+		}
+	}
+	return nil
+}
+
+// migrate upgrades a config file to the current schema version, one version
+// at a time. It returns the upgraded data along with the schema version the
+// file had, the data is returned unchanged when it is already current.
+func migrate(data []byte) ([]byte, int, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		logger.Log.Printf("Error unmarshaling config: %v", err)
+		return nil, 0, err
+	}
+	if doc == nil {
+		logger.Log.Println("Config is not an object")
+		return nil, 0, errors.New("config is not an object")
+	}
+
+	version := 0
+	if v, ok := doc["schemaVersion"].(float64); ok {
+		version = int(v)
+	}
+	if version > SchemaVersion {
+		logger.Log.Printf("Config schema version %d is newer than supported version %d", version, SchemaVersion)
+		return nil, version, fmt.Errorf("%w (schema version %d, this version supports %d), update Packsmith to open this project",
+			ErrNewerSchema, version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return data, version, nil
+	}
+
+	for v := version; v < SchemaVersion; v++ {
+		logger.Log.Printf("Migrating config from schema version %d to %d", v, v+1)
+		if err := migrations[v](doc); err != nil {
+			logger.Log.Printf("Error migrating config to schema version %d: %v", v+1, err)
+			return nil, version, fmt.Errorf("migrating packsmith.json to schema version %d: %w", v+1, err)
+		}
+	}
+	doc["schemaVersion"] = SchemaVersion
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		logger.Log.Printf("Error marshaling migrated config: %v", err)
+		return nil, version, err
+	}
+	return migrated, version, nil
+}
+
+// backupOriginal keeps a copy of a config file as it was before migrating
+// it from the given schema version. An existing backup is not overwritten.
+func backupOriginal(projectPath string, data []byte, version int) error {
+	backupFile := path.Join(projectPath, fmt.Sprintf("packsmith.v%d.json.bak", version))
+	if _, err := os.Stat(backupFile); err == nil {
+		logger.Log.Printf("Config backup already exists: %s", backupFile)
+		return nil
+	}
+	logger.Log.Printf("Backing up config to: %s", backupFile)
+	if err := os.WriteFile(backupFile, data, 0o644); err != nil {
+		logger.Log.Printf("Error backing up config: %v", err)
+		return err
+	}
+	return nil
+}
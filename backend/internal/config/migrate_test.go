@@ -0,0 +1,191 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantVersion int
+		wantErr     error
+		check       func(t *testing.T, doc map[string]any)
+	}{
+		{
+			name:        "version 0 without channel or mods",
+			data:        `{"name":"pack","minecraft":"1.20.1","loader":"fabric"}`,
+			wantVersion: 0,
+			check: func(t *testing.T, doc map[string]any) {
+				if doc["channel"] != ChannelRelease {
+					t.Errorf("channel = %v, want %q", doc["channel"], ChannelRelease)
+				}
+				if mods, ok := doc["mods"].(map[string]any); !ok || len(mods) != 0 {
+					t.Errorf("mods = %v, want empty object", doc["mods"])
+				}
+			},
+		},
+		{
+			name: "version 0 keeps channel",
+			data: `{"channel":"beta","mods":{}}`,
+			check: func(t *testing.T, doc map[string]any) {
+				if doc["channel"] != ChannelBeta {
+					t.Errorf("channel = %v, want %q", doc["channel"], ChannelBeta)
+				}
+			},
+		},
+		{
+			name: "version 0 records platforms from sources",
+			data: `{"mods":{
+				"sodium":{"source":"https://modrinth.com/mod/sodium"},
+				"jei":{"source":"https://www.curseforge.com/minecraft/mc-mods/jei"},
+				"custom":{"source":"https://example.com/custom.jar"},
+				"lithium":{"source":"https://www.curseforge.com/minecraft/mc-mods/lithium","platform":"modrinth"}
+			}}`,
+			check: func(t *testing.T, doc map[string]any) {
+				want := map[string]any{
+					"sodium":  "modrinth",
+					"jei":     "curseforge",
+					"custom":  nil,
+					"lithium": "modrinth",
+				}
+				mods := doc["mods"].(map[string]any)
+				for id, platform := range want {
+					if got := mods[id].(map[string]any)["platform"]; got != platform {
+						t.Errorf("mods[%s].platform = %v, want %v", id, got, platform)
+					}
+				}
+			},
+		},
+		{
+			name:    "mod that is not an object",
+			data:    `{"mods":{"sodium":"1.0"}}`,
+			wantErr: errors.New("mod sodium is not an object"),
+		},
+		{
+			name:        "newer schema",
+			data:        `{"schemaVersion":99}`,
+			wantVersion: 99,
+			wantErr:     ErrNewerSchema,
+		},
+		{
+			name:    "not an object",
+			data:    `null`,
+			wantErr: errors.New("config is not an object"),
+		},
+		{
+			name:    "invalid json",
+			data:    `{"mods":`,
+			wantErr: errors.New("unexpected end of JSON input"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, version, err := migrate([]byte(tt.data))
+			if version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("migrate() succeeded, want error %v", tt.wantErr)
+				}
+				if !errors.Is(err, tt.wantErr) && !containsError(err, tt.wantErr) {
+					t.Fatalf("migrate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrate() error = %v", err)
+			}
+
+			var doc map[string]any
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("migrated config is not valid JSON: %v", err)
+			}
+			if doc["schemaVersion"] != float64(SchemaVersion) {
+				t.Errorf("schemaVersion = %v, want %d", doc["schemaVersion"], SchemaVersion)
+			}
+			if tt.check != nil {
+				tt.check(t, doc)
+			}
+		})
+	}
+}
+
+func containsError(err, want error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err.Error() == want.Error() {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMigrateCurrentUnchanged(t *testing.T) {
+	data := []byte(`{"schemaVersion":1,"name":"pack","mods":{"a":{"source":"https://modrinth.com/mod/a"}}}`)
+	got, version, err := migrate(data)
+	if err != nil {
+		t.Fatalf("migrate() error = %v", err)
+	}
+	if version != SchemaVersion {
+		t.Errorf("version = %d, want %d", version, SchemaVersion)
+	}
+	if string(got) != string(data) {
+		t.Errorf("migrate() changed a current config:\n got %s\nwant %s", got, data)
+	}
+}
+
+func TestMigrationsCoverSchema(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Fatalf("%d migrations for schema version %d, every version needs one", len(migrations), SchemaVersion)
+	}
+}
+
+func TestLoadMigratesAndBacksUp(t *testing.T) {
+	dir := t.TempDir()
+	original := []byte(`{"name":"pack","minecraft":"1.20.1","loader":"fabric","mods":{"sodium":{"source":"https://modrinth.com/mod/sodium","version":"0.5"}}}`)
+	if err := os.WriteFile(filepath.Join(dir, "packsmith.json"), original, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.SchemaVersion != SchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", cfg.SchemaVersion, SchemaVersion)
+	}
+	if got := cfg.Mods["sodium"].Platform; got != "modrinth" {
+		t.Errorf("sodium platform = %q, want modrinth", got)
+	}
+	if cfg.ProjectPath() != dir {
+		t.Errorf("ProjectPath() = %q, want %q", cfg.ProjectPath(), dir)
+	}
+
+	backup, err := os.ReadFile(filepath.Join(dir, "packsmith.v0.json.bak"))
+	if err != nil {
+		t.Fatalf("reading backup: %v", err)
+	}
+	if string(backup) != string(original) {
+		t.Errorf("backup = %s, want the original file", backup)
+	}
+
+	// The migrated file is written back, so it loads without migrating.
+	if err := Check(dir); err != nil {
+		t.Errorf("Check() after migration error = %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package fs
 import (
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/sqot0/packsmith/backend/internal/logger"
 )
@@ -37,3 +38,37 @@ func Copy(src, dst string) error {
 	logger.Log.Println("File copied successfully")
 	return nil
 }
+
+// CopyToCache copies a file into the project cache under its own name and
+// returns that name.
+func CopyToCache(projectPath, src string) (string, error) {
+	cacheFolder := filepath.Join(projectPath, cacheDir)
+	logger.Log.Printf("Ensuring cache folder exists: %s", cacheFolder)
+	if err := os.MkdirAll(cacheFolder, 0o755); err != nil {
+		logger.Log.Printf("Error creating cache folder: %v", err)
+		return "", err
+	}
+
+	name := filepath.Base(src)
+	dst := filepath.Join(cacheFolder, name)
+	if sameFile(src, dst) {
+		logger.Log.Printf("File already in cache: %s", name)
+		return name, nil
+	}
+	if err := Copy(src, dst); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
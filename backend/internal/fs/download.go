@@ -1,6 +1,9 @@
 package fs
 
 import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,49 +16,91 @@ import (
 
 const cacheDir = "cache"
 
-func Download(projectPath, fileURL, version string) (string, error) {
+// Downloaded is a file downloaded into the project cache, with the hex
+// encoded digests computed while it was downloaded.
+type Downloaded struct {
+	Name   string
+	SHA1   string
+	SHA256 string
+}
+
+func Download(client *http.Client, projectPath, fileURL, version string) (Downloaded, error) {
+	tmp, file, err := DownloadTemp(client, projectPath, fileURL, version)
+	if err != nil {
+		return Downloaded{}, err
+	}
+	if err := MoveToCache(projectPath, tmp, file.Name); err != nil {
+		return Downloaded{}, err
+	}
+	return file, nil
+}
+
+// DownloadTemp downloads a file into a temp file in the project cache and
+// returns its path along with the name the file should have in the cache.
+// The caller moves it into place with MoveToCache or removes it.
+func DownloadTemp(client *http.Client, projectPath, fileURL, version string) (string, Downloaded, error) {
 	logger.Log.Printf("Downloading file from URL: %s", fileURL)
-	resp, err := http.Get(fileURL)
+	resp, err := client.Get(fileURL)
 	if err != nil {
 		logger.Log.Printf("Error making HTTP request: %v", err)
-		return "", err
+		return "", Downloaded{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		logger.Log.Printf("Download failed with status: %s", resp.Status)
-		return "", fmt.Errorf("download failed: %s", resp.Status)
+		return "", Downloaded{}, fmt.Errorf("download failed: %s", resp.Status)
 	}
 
 	cacheFolder := filepath.Join(projectPath, cacheDir)
 	logger.Log.Printf("Ensuring cache folder exists: %s", cacheFolder)
 	if err := os.MkdirAll(cacheFolder, 0o755); err != nil {
 		logger.Log.Printf("Error creating cache folder: %v", err)
-		return "", err
+		return "", Downloaded{}, err
 	}
 
 	name := getFilename(resp, version)
 	if name == "" {
 		logger.Log.Println("Could not determine filename")
-		return "", fmt.Errorf("could not determine filename")
+		return "", Downloaded{}, fmt.Errorf("could not determine filename")
 	}
 	logger.Log.Printf("Determined filename: %s", name)
 
-	out, err := os.Create(filepath.Join(cacheFolder, name))
+	out, err := os.CreateTemp(cacheFolder, ".download-*")
 	if err != nil {
 		logger.Log.Printf("Error creating file: %v", err)
-		return "", err
+		return "", Downloaded{}, err
 	}
-	defer out.Close()
 
 	logger.Log.Println("Copying file content")
-	_, err = io.Copy(out, resp.Body)
+	sum1, sum256 := sha1.New(), sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, sum1, sum256), resp.Body)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
 	if err != nil {
+		os.Remove(out.Name())
 		logger.Log.Printf("Error copying file content: %v", err)
-		return "", err
+		return "", Downloaded{}, err
 	}
 	logger.Log.Println("File downloaded successfully")
-	return name, nil
+	return out.Name(), Downloaded{
+		Name:   name,
+		SHA1:   hex.EncodeToString(sum1.Sum(nil)),
+		SHA256: hex.EncodeToString(sum256.Sum(nil)),
+	}, nil
+}
+
+// MoveToCache moves a file downloaded with DownloadTemp into the project
+// cache under the given name, replacing any file of that name.
+func MoveToCache(projectPath, tmp, name string) error {
+	dst := filepath.Join(projectPath, cacheDir, name)
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		logger.Log.Printf("Error moving download into cache: %v", err)
+		return err
+	}
+	return nil
 }
 
 func getFilename(resp *http.Response, version string) string {
@@ -0,0 +1,38 @@
+package fs
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+// SHA256 returns the hex encoded SHA-256 digest of a file.
+func SHA256(filePath string) (string, error) {
+	return hashFile(filePath, sha256.New())
+}
+
+// SHA1 returns the hex encoded SHA-1 digest of a file.
+func SHA1(filePath string) (string, error) {
+	return hashFile(filePath, sha1.New())
+}
+
+func hashFile(filePath string, h hash.Hash) (string, error) {
+	logger.Log.Printf("Hashing file: %s", filePath)
+	f, err := os.Open(filePath)
+	if err != nil {
+		logger.Log.Printf("Error opening file: %v", err)
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		logger.Log.Printf("Error reading file: %v", err)
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
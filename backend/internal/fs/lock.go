@@ -0,0 +1,56 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+// ErrLocked is returned by LockFile when another process holds the lock.
+var ErrLocked = errors.New("locked by another process")
+
+// Lock is an exclusive OS-level lock on a file. The operating system
+// releases it when the process exits, so a crash never leaves it stale.
+type Lock struct {
+	file *os.File
+}
+
+// LockFile creates the file if needed and locks it without waiting. The
+// file holds the ID of the process that owns the lock, for troubleshooting.
+func LockFile(path string) (*Lock, error) {
+	logger.Log.Printf("Locking file: %s", path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		logger.Log.Printf("Error creating lock folder: %v", err)
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		logger.Log.Printf("Error opening lock file: %v", err)
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		logger.Log.Printf("Error locking file: %v", err)
+		return nil, err
+	}
+
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	return &Lock{file: f}, nil
+}
+
+// Unlock releases the lock. The lock file is left in place, removing it
+// would race with another process locking it.
+func (l *Lock) Unlock() error {
+	logger.Log.Printf("Unlocking file: %s", l.file.Name())
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		logger.Log.Printf("Error unlocking file: %v", err)
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/sources"
+)
+
+// hashFile computes the hashes used by the platforms to identify a jar. The
+// file is streamed twice rather than read into memory, the fingerprint
+// needs the length of the normalized file before it can start.
+func hashFile(filePath string) (sources.FileHashes, error) {
+	logger.Log.Printf("Hashing file: %s", filePath)
+	f, err := os.Open(filePath)
+	if err != nil {
+		logger.Log.Printf("Error opening file: %v", err)
+		return sources.FileHashes{}, err
+	}
+	defer f.Close()
+
+	sum1, sum512 := sha1.New(), sha512.New()
+	var counter normalizedCounter
+	if _, err := io.Copy(io.MultiWriter(sum1, sum512, &counter), f); err != nil {
+		logger.Log.Printf("Error reading file: %v", err)
+		return sources.FileHashes{}, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		logger.Log.Printf("Error rewinding file: %v", err)
+		return sources.FileHashes{}, err
+	}
+	fingerprint := newFingerprint(counter.n)
+	if _, err := io.Copy(fingerprint, f); err != nil {
+		logger.Log.Printf("Error reading file: %v", err)
+		return sources.FileHashes{}, err
+	}
+
+	return sources.FileHashes{
+		SHA1:    hex.EncodeToString(sum1.Sum(nil)),
+		SHA512:  hex.EncodeToString(sum512.Sum(nil)),
+		Murmur2: fingerprint.Sum32(),
+	}, nil
+}
+
+// curseforgeFingerprint is the 32-bit MurmurHash2 with seed 1 that
+// CurseForge computes over a file with all whitespace bytes removed.
+func curseforgeFingerprint(data []byte) uint32 {
+	var counter normalizedCounter
+	counter.Write(data)
+	fingerprint := newFingerprint(counter.n)
+	fingerprint.Write(data)
+	return fingerprint.Sum32()
+}
+
+func isFingerprintWhitespace(b byte) bool {
+	return b == 9 || b == 10 || b == 13 || b == 32
+}
+
+// normalizedCounter counts the bytes that are not whitespace.
+type normalizedCounter struct {
+	n int
+}
+
+func (c *normalizedCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if !isFingerprintWhitespace(b) {
+			c.n++
+		}
+	}
+	return len(p), nil
+}
+
+const (
+	murmurM = 0x5bd1e995
+	murmurR = 24
+)
+
+// fingerprint computes the CurseForge fingerprint of a stream whose
+// normalized length is known up front.
+type fingerprint struct {
+	h     uint32
+	tail  [4]byte
+	ntail int
+}
+
+func newFingerprint(normalizedLen int) *fingerprint {
+	return &fingerprint{h: uint32(1) ^ uint32(normalizedLen)}
+}
+
+func (f *fingerprint) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if isFingerprintWhitespace(b) {
+			continue
+		}
+		f.tail[f.ntail] = b
+		f.ntail++
+		if f.ntail < 4 {
+			continue
+		}
+		k := binary.LittleEndian.Uint32(f.tail[:])
+		k *= murmurM
+		k ^= k >> murmurR
+		k *= murmurM
+		f.h *= murmurM
+		f.h ^= k
+		f.ntail = 0
+	}
+	return len(p), nil
+}
+
+func (f *fingerprint) Sum32() uint32 {
+	h := f.h
+	switch f.ntail {
+	case 3:
+		h ^= uint32(f.tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(f.tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(f.tail[0])
+		h *= murmurM
+	}
+
+	h ^= h >> 13
+	h *= murmurM
+	h ^= h >> 15
+	return h
+}
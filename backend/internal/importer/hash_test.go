@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+func TestCurseforgeFingerprint(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want uint32
+	}{
+		{name: "empty", data: "", want: 0x5bd15e36},
+		{name: "whitespace only", data: " \t\r\n", want: 0x5bd15e36},
+		{name: "one byte tail", data: "a", want: 0x2550b18c},
+		{name: "two byte tail", data: "ab", want: 0x64e150ee},
+		{name: "three byte tail", data: "abc", want: 0x60a4fcc1},
+		{name: "one block", data: "abcd", want: 0xc93f7a16},
+		{name: "whitespace removed", data: "hello world", want: 0xa85cbded},
+		{name: "whitespace removed between blocks", data: "hello\tw\r\norld", want: 0xa85cbded},
+		{name: "trailing newline", data: "Hello, World!\n", want: 0x74e5d78b},
+		{name: "sentence", data: "The quick brown fox jumps over the lazy dog", want: 0xdf9f94f7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := curseforgeFingerprint([]byte(tt.data)); got != tt.want {
+				t.Errorf("curseforgeFingerprint(%q) = %#08x, want %#08x", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+// The fingerprint is streamed, so it must not depend on how the input is
+// split into writes.
+func TestFingerprintChunked(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, 4099)
+	for i := range data {
+		// Plenty of whitespace so blocks straddle the skipped bytes.
+		data[i] = " \tab\ncd\rxyz"[rng.IntN(11)]
+	}
+	want := curseforgeFingerprint(data)
+
+	for _, size := range []int{1, 2, 3, 5, 7, 64, 4096} {
+		var counter normalizedCounter
+		counter.Write(data)
+		f := newFingerprint(counter.n)
+		for i := 0; i < len(data); i += size {
+			f.Write(data[i:min(i+size, len(data))])
+		}
+		if got := f.Sum32(); got != want {
+			t.Errorf("chunk size %d: fingerprint = %#08x, want %#08x", size, got, want)
+		}
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	data := []byte("PK\x03\x04 fake jar\ncontents\r\n\twith whitespace")
+	file := filepath.Join(t.TempDir(), "mod.jar")
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	hashes, err := hashFile(file)
+	if err != nil {
+		t.Fatalf("hashFile() error = %v", err)
+	}
+	sum1 := sha1.Sum(data)
+	sum512 := sha512.Sum512(data)
+	if want := hex.EncodeToString(sum1[:]); hashes.SHA1 != want {
+		t.Errorf("SHA1 = %s, want %s", hashes.SHA1, want)
+	}
+	if want := hex.EncodeToString(sum512[:]); hashes.SHA512 != want {
+		t.Errorf("SHA512 = %s, want %s", hashes.SHA512, want)
+	}
+	if want := curseforgeFingerprint(data); hashes.Murmur2 != want {
+		t.Errorf("Murmur2 = %#08x, want %#08x", hashes.Murmur2, want)
+	}
+}
@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/fs"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/sources"
+	"github.com/sqot0/packsmith/backend/internal/util"
+)
+
+// ImportedFile is the outcome of importing a single jar.
+type ImportedFile struct {
+	FileName string
+	ModID    string
+	Platform string
+	Version  string
+	Error    string
+}
+
+// Report lists what happened to every jar of an imported folder. Skipped
+// jars belong to mods that are already in the project. Conflicts are
+// imported jars whose version is not built for the project's Minecraft
+// version or loader, with the reason in Error.
+type Report struct {
+	Imported  []ImportedFile
+	Local     []ImportedFile
+	Skipped   []ImportedFile
+	Failed    []ImportedFile
+	Conflicts []ImportedFile
+}
+
+type hashedFile struct {
+	path   string
+	hashes sources.FileHashes
+	err    error
+}
+
+// ImportFolder adds every jar in dir to the project. Jars are identified on
+// the mod platforms by their hashes, jars that are not recognised are added
+// as local mods. The config is changed in place and not saved.
+func ImportFolder(s *sources.Settings, cfg *config.Config, projectPath, dir string) (*Report, error) {
+	logger.Log.Printf("Importing mods from folder: %s", dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		logger.Log.Printf("Error reading folder: %v", err)
+		return nil, err
+	}
+
+	var jars []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".jar") {
+			jars = append(jars, filepath.Join(dir, entry.Name()))
+		}
+	}
+	logger.Log.Printf("Found %d jars to import", len(jars))
+
+	report := &Report{}
+	files := hashFiles(jars)
+
+	var hashed []hashedFile
+	var hashes []sources.FileHashes
+	for _, f := range files {
+		if f.err != nil {
+			report.Failed = append(report.Failed, ImportedFile{FileName: filepath.Base(f.path), Error: f.err.Error()})
+			continue
+		}
+		hashed = append(hashed, f)
+		hashes = append(hashes, f.hashes)
+	}
+
+	identified := sources.IdentifyFiles(s, cfg, hashes)
+	for i, f := range hashed {
+		fileName := filepath.Base(f.path)
+		match, ok := identified[i]
+		if !ok {
+			importLocal(cfg, projectPath, f.path, report)
+			continue
+		}
+
+		if _, exists := cfg.Mods[match.ModID]; exists {
+			logger.Log.Printf("Skipping %s, mod %s is already in the project", fileName, match.ModID)
+			report.Skipped = append(report.Skipped, ImportedFile{FileName: fileName, ModID: match.ModID, Platform: match.Platform, Version: match.Version.Version})
+			continue
+		}
+
+		if err := cfg.CheckCacheName(fileName); err != nil {
+			report.Failed = append(report.Failed, ImportedFile{FileName: fileName, ModID: match.ModID, Error: err.Error()})
+			continue
+		}
+		filename, err := fs.CopyToCache(projectPath, f.path)
+		if err != nil {
+			report.Failed = append(report.Failed, ImportedFile{FileName: fileName, ModID: match.ModID, Error: err.Error()})
+			continue
+		}
+		cfg.Mods[match.ModID] = config.Mod{
+			Platform:  match.Platform,
+			ProjectID: match.Version.ProjectID,
+			Source:    match.Source,
+			Side:      match.Side,
+			Version:   match.Version.Version,
+			VersionID: match.Version.ID,
+			URL:       match.Version.DownloadURL,
+			Filename:  filename,
+			SHA1:      f.hashes.SHA1,
+		}
+		logger.Log.Printf("Imported %s as %s %s from %s", fileName, match.ModID, match.Version.Version, match.Platform)
+		imported := ImportedFile{FileName: fileName, ModID: match.ModID, Platform: match.Platform, Version: match.Version.Version}
+		report.Imported = append(report.Imported, imported)
+		if reason := incompatibility(cfg, match.Version); reason != "" {
+			logger.Log.Printf("%s is %s", fileName, reason)
+			imported.Error = reason
+			report.Conflicts = append(report.Conflicts, imported)
+		}
+	}
+
+	logger.Log.Printf("Import finished: %d imported, %d local, %d skipped, %d failed, %d conflicts",
+		len(report.Imported), len(report.Local), len(report.Skipped), len(report.Failed), len(report.Conflicts))
+	return report, nil
+}
+
+func importLocal(cfg *config.Config, projectPath, filePath string, report *Report) {
+	fileName := filepath.Base(filePath)
+	modID := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	if _, exists := cfg.Mods[modID]; exists {
+		logger.Log.Printf("Skipping %s, mod %s is already in the project", fileName, modID)
+		report.Skipped = append(report.Skipped, ImportedFile{FileName: fileName, ModID: modID, Platform: config.PlatformLocal})
+		return
+	}
+
+	if err := cfg.CheckCacheName(fileName); err != nil {
+		report.Failed = append(report.Failed, ImportedFile{FileName: fileName, ModID: modID, Error: err.Error()})
+		return
+	}
+
+	hash, err := fs.SHA256(filePath)
+	if err != nil {
+		report.Failed = append(report.Failed, ImportedFile{FileName: fileName, ModID: modID, Error: err.Error()})
+		return
+	}
+	filename, err := fs.CopyToCache(projectPath, filePath)
+	if err != nil {
+		report.Failed = append(report.Failed, ImportedFile{FileName: fileName, ModID: modID, Error: err.Error()})
+		return
+	}
+	cfg.Mods[modID] = config.Mod{
+		Platform: config.PlatformLocal,
+		Side:     "both",
+		Filename: filename,
+		Hash:     hash,
+	}
+	logger.Log.Printf("Imported %s as local mod %s", fileName, modID)
+	report.Local = append(report.Local, ImportedFile{FileName: fileName, ModID: modID, Platform: config.PlatformLocal})
+}
+
+// incompatibility describes why a version does not run on the project, or
+// returns "" when it does. Versions that do not name their game versions or
+// loaders are taken to support the project.
+func incompatibility(cfg *config.Config, v sources.ModVersion) string {
+	if len(v.GameVersions) > 0 && !slices.Contains(v.GameVersions, cfg.Minecraft) {
+		return fmt.Sprintf("built for Minecraft %s, not %s", strings.Join(v.GameVersions, ", "), cfg.Minecraft)
+	}
+	accepted := sources.AcceptedLoaders(cfg.Loader, cfg.Minecraft)
+	if len(v.Loaders) > 0 && !slices.ContainsFunc(accepted, func(l string) bool { return slices.Contains(v.Loaders, l) }) {
+		return fmt.Sprintf("built for %s, not %s", strings.Join(v.Loaders, ", "), cfg.Loader)
+	}
+	return ""
+}
+
+// hashFiles hashes the files in parallel, keeping their order.
+func hashFiles(paths []string) []hashedFile {
+	type job struct {
+		index int
+		path  string
+	}
+	type result struct {
+		index int
+		file  hashedFile
+	}
+
+	processJob := func(j job) result {
+		hashes, err := hashFile(j.path)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", filepath.Base(j.path), err)
+		}
+		return result{index: j.index, file: hashedFile{path: j.path, hashes: hashes, err: err}}
+	}
+
+	files := make([]hashedFile, len(paths))
+	if len(paths) == 0 {
+		return files
+	}
+
+	jobs := make(chan job)
+	results := util.WorkerPool(jobs, processJob, util.Workers(len(paths)))
+
+	go func() {
+		for i, p := range paths {
+			jobs <- job{index: i, path: p}
+		}
+		close(jobs)
+	}()
+
+	for r := range results {
+		files[r.index] = r.file
+	}
+	return files
+}
@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/sources"
+)
+
+func sha1Hex(data string) string {
+	sum := sha1.Sum([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// newModrinthServer identifies jars by the SHA-1 of their contents.
+func newModrinthServer(t *testing.T, versions map[string]sources.ModrinthModVersion, projects []sources.ModrinthProject) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp any
+		switch r.Method + " " + r.URL.Path {
+		case "POST /version_files":
+			var body struct {
+				Hashes    []string `json:"hashes"`
+				Algorithm string   `json:"algorithm"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding body: %v", err)
+			}
+			found := map[string]sources.ModrinthModVersion{}
+			for _, hash := range body.Hashes {
+				if v, ok := versions[hash]; ok && body.Algorithm == "sha1" {
+					found[hash] = v
+				}
+			}
+			resp = found
+		case "GET /projects":
+			resp = projects
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestImportFolder(t *testing.T) {
+	jars := map[string]string{
+		"sodium.jar":   "sodium",
+		"forgemod.jar": "forgemod",
+		"taken.jar":    "local jar",
+	}
+	versions := map[string]sources.ModrinthModVersion{
+		sha1Hex("sodium"):   {ID: "s1", ProjectID: "P1", Version: "0.5.0", GameVersions: []string{"1.20.1"}, Loaders: []string{"fabric"}},
+		sha1Hex("forgemod"): {ID: "f1", ProjectID: "P2", Version: "2.0", GameVersions: []string{"1.20.1"}, Loaders: []string{"forge"}},
+	}
+	projects := []sources.ModrinthProject{{ID: "P1", Slug: "sodium"}, {ID: "P2", Slug: "forgemod"}}
+	srv := newModrinthServer(t, versions, projects)
+	s := &sources.Settings{ModrinthURL: srv.URL, Client: srv.Client()}
+
+	projectPath := t.TempDir()
+	if err := config.Init(projectPath, "test", "1.20.1", "fabric", ""); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A mod of another name already owns taken.jar in the cache.
+	cfg.Mods["owner"] = config.Mod{Platform: config.PlatformLocal, Filename: "TAKEN.jar"}
+
+	dir := t.TempDir()
+	for name, data := range jars {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := ImportFolder(s, cfg, projectPath, dir)
+	if err != nil {
+		t.Fatalf("ImportFolder() error = %v", err)
+	}
+	if len(report.Imported) != 2 || len(report.Local) != 0 {
+		t.Errorf("imported %v and local %v, want sodium and forgemod identified", report.Imported, report.Local)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].FileName != "taken.jar" {
+		t.Errorf("failed = %v, want taken.jar", report.Failed)
+	}
+	if _, err := os.Stat(filepath.Join(projectPath, "cache", "taken.jar")); !os.IsNotExist(err) {
+		t.Error("taken.jar was copied over the jar of another mod")
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0].ModID != "forgemod" || report.Conflicts[0].Error == "" {
+		t.Errorf("conflicts = %v, want forgemod built for another loader", report.Conflicts)
+	}
+	if mod := cfg.Mods["sodium"]; mod.Filename != "sodium.jar" || mod.SHA1 != sha1Hex("sodium") {
+		t.Errorf("sodium = %+v", mod)
+	}
+}
+
+func TestIncompatibility(t *testing.T) {
+	cfg := &config.Config{Minecraft: "1.20.1", Loader: "neoforge"}
+	tests := []struct {
+		name string
+		v    sources.ModVersion
+		want bool
+	}{
+		{name: "matching", v: sources.ModVersion{GameVersions: []string{"1.20.1"}, Loaders: []string{"neoforge"}}},
+		{name: "fallback loader", v: sources.ModVersion{GameVersions: []string{"1.20.1"}, Loaders: []string{"forge"}}},
+		{name: "unknown", v: sources.ModVersion{}},
+		{name: "other minecraft", v: sources.ModVersion{GameVersions: []string{"1.19.2"}, Loaders: []string{"neoforge"}}, want: true},
+		{name: "other loader", v: sources.ModVersion{GameVersions: []string{"1.20.1"}, Loaders: []string{"fabric"}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := incompatibility(cfg, tt.v); (got != "") != tt.want {
+				t.Errorf("incompatibility() = %q, want incompatible %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package installer
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/sqot0/packsmith/backend/internal/config"
 	"github.com/sqot0/packsmith/backend/internal/fs"
@@ -10,13 +13,11 @@ import (
 	"github.com/sqot0/packsmith/backend/internal/util"
 )
 
-func InstallMods(projectPath string) error {
+// InstallMods rebuilds the client and server folders of the project from
+// the mods in cfg, downloading any missing from the cache.
+func InstallMods(client *http.Client, cfg *config.Config) error {
+	projectPath := cfg.ProjectPath()
 	logger.Log.Printf("Installing mods for project: %s", projectPath)
-	cfg, err := config.Load(projectPath)
-	if err != nil {
-		logger.Log.Printf("Error loading config: %v", err)
-		return err
-	}
 
 	cacheFolder := path.Join(projectPath, "cache")
 	clientFolder := path.Join(projectPath, "client")
@@ -39,11 +40,26 @@ func InstallMods(projectPath string) error {
 		cacheMod := path.Join(cacheFolder, mod.Filename)
 
 		if _, err := os.Stat(cacheMod); os.IsNotExist(err) {
+			if mod.URL == "" {
+				logger.Log.Printf("Mod %s is missing from the cache and has no download URL", mod.Filename)
+				return fmt.Errorf("%s is missing from the cache", mod.Filename)
+			}
 			logger.Log.Printf("Mod not in cache, downloading: %s", mod.URL)
-			if _, err := fs.Download(projectPath, mod.URL, mod.Version); err != nil {
+			tmp, file, err := fs.DownloadTemp(client, projectPath, mod.URL, mod.Filename)
+			if err != nil {
 				logger.Log.Printf("Error downloading mod: %v", err)
 				return err
 			}
+			if err := verifyDownload(mod, file); err != nil {
+				os.Remove(tmp)
+				return err
+			}
+			// The name the server gives the file can differ from the one
+			// recorded when the mod was added, such as for redirected
+			// release assets.
+			if err := fs.MoveToCache(projectPath, tmp, mod.Filename); err != nil {
+				return err
+			}
 		}
 
 		switch mod.Side {
@@ -77,7 +93,7 @@ func InstallMods(projectPath string) error {
 	}
 
 	jobs := make(chan config.Mod, len(cfg.Mods))
-	results := util.WorkerPool(jobs, processMod, len(cfg.Mods))
+	results := util.WorkerPool(jobs, processMod, util.Workers(len(cfg.Mods)))
 
 	go func() {
 		for _, mod := range cfg.Mods {
@@ -96,3 +112,18 @@ func InstallMods(projectPath string) error {
 	logger.Log.Println("Mods installed successfully")
 	return nil
 }
+
+// verifyDownload checks a downloaded file against the SHA-256 hash and the
+// SHA-1 digest recorded for the mod. Digests that were not recorded are not
+// checked.
+func verifyDownload(mod config.Mod, file fs.Downloaded) error {
+	if mod.Hash != "" && !strings.EqualFold(file.SHA256, mod.Hash) {
+		logger.Log.Printf("Hash mismatch for %s: expected %s, got %s", mod.Filename, mod.Hash, file.SHA256)
+		return fmt.Errorf("%s does not match its expected hash", mod.Filename)
+	}
+	if mod.SHA1 != "" && !strings.EqualFold(file.SHA1, mod.SHA1) {
+		logger.Log.Printf("SHA-1 mismatch for %s: expected %s, got %s", mod.Filename, mod.SHA1, file.SHA1)
+		return fmt.Errorf("%s does not match the file that was added", mod.Filename)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package installer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+const jar = "fake jar contents"
+
+func jarSHA1() string {
+	sum := sha1.Sum([]byte(jar))
+	return hex.EncodeToString(sum[:])
+}
+
+// newProject writes a config with the given mods and loads it, so the
+// config knows its project path.
+func newProject(t *testing.T, mods map[string]config.Mod) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	data, err := json.Marshal(config.Config{SchemaVersion: config.SchemaVersion, Mods: mods})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "packsmith.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func newJarServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, jar)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInstallDownloadsUnderRecordedName(t *testing.T) {
+	srv := newJarServer(t)
+	cfg := newProject(t, map[string]config.Mod{
+		// Release asset URLs often redirect to a path with another name.
+		"mod": {Side: "both", Filename: "mod-1.0.jar", URL: srv.URL + "/objects/12345", SHA1: jarSHA1()},
+	})
+
+	if err := InstallMods(srv.Client(), cfg); err != nil {
+		t.Fatalf("InstallMods() error = %v", err)
+	}
+	for _, dir := range []string{"cache", "client", "server"} {
+		data, err := os.ReadFile(filepath.Join(cfg.ProjectPath(), dir, "mod-1.0.jar"))
+		if err != nil || string(data) != jar {
+			t.Errorf("%s/mod-1.0.jar = %q, %v, want the downloaded jar", dir, data, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ProjectPath(), "cache", "12345")); !os.IsNotExist(err) {
+		t.Errorf("jar was also stored under the URL name")
+	}
+}
+
+func TestInstallRejectsChangedFile(t *testing.T) {
+	tests := []struct {
+		name string
+		mod  config.Mod
+	}{
+		{name: "sha1", mod: config.Mod{SHA1: "0000000000000000000000000000000000000000"}},
+		{name: "sha256", mod: config.Mod{Hash: "00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newJarServer(t)
+			mod := tt.mod
+			mod.Side, mod.Filename, mod.URL = "client", "mod.jar", srv.URL+"/mod.jar"
+			cfg := newProject(t, map[string]config.Mod{"mod": mod})
+
+			if err := InstallMods(srv.Client(), cfg); err == nil {
+				t.Fatal("InstallMods() accepted a jar that does not match its digest")
+			}
+			entries, err := os.ReadDir(filepath.Join(cfg.ProjectPath(), "cache"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("cache = %v, want the rejected download removed", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package sources
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+const DefaultCacheTTL = 10 * time.Minute
+
+// cacheMaxAge is how long an entry is kept after it was last stored. Expired
+// entries are kept until then so they can still be served offline.
+const cacheMaxAge = 7 * 24 * time.Hour
+
+// HTTPCache stores source API responses on disk. Entries younger than the
+// TTL are served without a request, older entries are revalidated with
+// If-None-Match and If-Modified-Since when the platform sent validators.
+type HTTPCache struct {
+	dir string
+	ttl time.Duration
+}
+
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	StatusCode   int         `json:"statusCode"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"lastModified"`
+	StoredAt     time.Time   `json:"storedAt"`
+}
+
+// DefaultCacheDir returns the response cache directory inside the user
+// cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "packsmith", "http"), nil
+}
+
+// NewHTTPCache creates the cache directory if needed and prunes entries
+// older than cacheMaxAge.
+func NewHTTPCache(dir string, ttl time.Duration) (*HTTPCache, error) {
+	logger.Log.Printf("Using HTTP cache at %s with TTL %s", dir, ttl)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		logger.Log.Printf("Error creating cache directory: %v", err)
+		return nil, err
+	}
+	c := &HTTPCache{dir: dir, ttl: ttl}
+	c.prune(cacheMaxAge)
+	return c, nil
+}
+
+// prune removes entries, and temporary files left by interrupted writes,
+// that were last written more than maxAge ago. Errors are only logged, a
+// cache that cannot be pruned still works.
+func (c *HTTPCache) prune(maxAge time.Duration) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		logger.Log.Printf("Error reading cache directory: %v", err)
+		return
+	}
+	removed := 0
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || info.IsDir() || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, e.Name())); err != nil {
+			logger.Log.Printf("Error removing cache entry: %v", err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		logger.Log.Printf("Pruned %d old entries from HTTP cache", removed)
+	}
+}
+
+// Clear removes every cached response.
+func (c *HTTPCache) Clear() error {
+	logger.Log.Printf("Clearing HTTP cache at %s", c.dir)
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.Remove(filepath.Join(c.dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *HTTPCache) path(reqURL string) string {
+	sum := sha256.Sum256([]byte(reqURL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *HTTPCache) load(reqURL string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(reqURL))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != reqURL {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// store writes the entry to a temporary file first so concurrent readers
+// never see a partial entry.
+func (c *HTTPCache) store(entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger.Log.Printf("Error encoding cache entry: %v", err)
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		logger.Log.Printf("Error creating cache entry: %v", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(entry.URL))
+	}
+	if err != nil {
+		logger.Log.Printf("Error writing cache entry: %v", err)
+		os.Remove(tmp.Name())
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// do sends a source request, answering GET requests from the cache when
+// possible and storing successful responses.
+func (s *Settings) do(req *http.Request) (*http.Response, error) {
+	if s.Cache == nil || req.Method != http.MethodGet {
+		return s.send(req)
+	}
+
+	reqURL := req.URL.String()
+	entry, cached := s.Cache.load(reqURL)
+	if cached && !s.refresh && time.Since(entry.StoredAt) < s.Cache.ttl {
+		logger.Log.Printf("Serving cached response for: %s", reqURL)
+		return entry.response(req), nil
+	}
+	if cached {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := s.send(req)
+	if err != nil {
+		if cached && s.stale {
+			logger.Log.Printf("Serving expired cached response for %s: %v", reqURL, err)
+			return entry.response(req), nil
+		}
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		logger.Log.Printf("Cached response still valid for: %s", reqURL)
+		entry.StoredAt = time.Now()
+		s.Cache.store(entry)
+		return entry.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		logger.Log.Printf("Error reading response body: %v", err)
+		return nil, err
+	}
+	entry = &cacheEntry{
+		URL:          reqURL,
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header,
+		Body:         body,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StoredAt:     time.Now(),
+	}
+	s.Cache.store(entry)
+	return entry.response(req), nil
+}
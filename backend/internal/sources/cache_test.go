@@ -0,0 +1,206 @@
+package sources
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fetchBody reads a URL through the request layer.
+func fetchBody(s *Settings, reqURL string) (string, error) {
+	var body string
+	err := s.Fetch("test", reqURL, func(r io.Reader) error {
+		data, err := io.ReadAll(r)
+		body = string(data)
+		return err
+	})
+	return body, err
+}
+
+func testCache(t *testing.T, ttl time.Duration) *HTTPCache {
+	t.Helper()
+	cache, err := NewHTTPCache(t.TempDir(), ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func TestCacheServesFreshResponses(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		io.WriteString(w, "v1")
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+	s.Cache = testCache(t, time.Hour)
+
+	for range 3 {
+		body, err := fetchBody(s, srv.URL+"/catalog")
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if body != "v1" {
+			t.Errorf("body = %q, want v1", body)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1 with the rest served from the cache", got)
+	}
+
+	// Other URLs and requests other than GET are not answered from the cache.
+	if _, err := fetchBody(s, srv.URL+"/catalog?page=2"); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	req, _ := s.newRequest(http.MethodPost, srv.URL+"/catalog", strings.NewReader("{}"))
+	resp, err := s.do(req)
+	if err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+	resp.Body.Close()
+	if got := requests.Load(); got != 3 {
+		t.Errorf("made %d requests, want 3", got)
+	}
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator string
+		value     string
+		condition string
+	}{
+		{name: "etag", validator: "ETag", value: `"abc"`, condition: "If-None-Match"},
+		{name: "last modified", validator: "Last-Modified", value: "Wed, 21 Oct 2026 07:28:00 GMT", condition: "If-Modified-Since"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests, revalidated atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				if r.Header.Get(tt.condition) == tt.value {
+					revalidated.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set(tt.validator, tt.value)
+				io.WriteString(w, "cached body")
+			}))
+			t.Cleanup(srv.Close)
+			s := testSettings(srv)
+			s.Cache = testCache(t, time.Hour)
+
+			if _, err := fetchBody(s, srv.URL); err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			// Force refresh revalidates the fresh entry and serves the
+			// cached body on 304.
+			body, err := fetchBody(s.WithForceRefresh(), srv.URL)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if body != "cached body" {
+				t.Errorf("body after 304 = %q, want the cached body", body)
+			}
+			if requests.Load() != 2 || revalidated.Load() != 1 {
+				t.Errorf("made %d requests with %d revalidated, want 2 and 1", requests.Load(), revalidated.Load())
+			}
+		})
+	}
+}
+
+func TestCacheExpiredEntryRevalidated(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if n > 1 && r.Header.Get("If-None-Match") != `"v1"` {
+			t.Errorf("request %d without If-None-Match", n)
+		}
+		w.Header().Set("ETag", `"v2"`)
+		io.WriteString(w, "v2")
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+	s.Cache = testCache(t, time.Nanosecond)
+	s.Cache.store(&cacheEntry{URL: srv.URL, StatusCode: http.StatusOK, Body: []byte("v1"), ETag: `"v1"`, StoredAt: time.Now()})
+	requests.Store(1)
+
+	// The entry changed on the server, so the new body replaces it.
+	body, err := fetchBody(s, srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if body != "v2" {
+		t.Errorf("body = %q, want v2", body)
+	}
+	entry, ok := s.Cache.load(srv.URL)
+	if !ok || string(entry.Body) != "v2" || entry.ETag != `"v2"` {
+		t.Errorf("cached entry = %+v, want the new response", entry)
+	}
+}
+
+func TestStaleCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "catalog")
+	}))
+	s := testSettings(srv)
+	s.Cache = testCache(t, time.Nanosecond)
+
+	if _, err := fetchBody(s, srv.URL); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	srv.Close()
+
+	if _, err := fetchBody(s, srv.URL); err == nil {
+		t.Error("Fetch() of an expired entry from an unreachable host succeeded")
+	}
+	body, err := fetchBody(s.WithStaleCache(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() with stale cache error = %v", err)
+	}
+	if body != "catalog" {
+		t.Errorf("body = %q, want the expired cached body", body)
+	}
+}
+
+func TestCachePrunedAtStartup(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewHTTPCache(dir, DefaultCacheTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.store(&cacheEntry{URL: "https://example.com/recent", StatusCode: http.StatusOK, StoredAt: time.Now()})
+	cache.store(&cacheEntry{URL: "https://example.com/old", StatusCode: http.StatusOK, StoredAt: time.Now()})
+	leftover := filepath.Join(dir, "entry-1.tmp")
+	if err := os.WriteFile(leftover, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-cacheMaxAge - time.Hour)
+	for _, path := range []string{cache.path("https://example.com/old"), leftover} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache, err = NewHTTPCache(dir, DefaultCacheTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.load("https://example.com/recent"); !ok {
+		t.Error("recent entry was pruned")
+	}
+	if _, ok := cache.load("https://example.com/old"); ok {
+		t.Error("old entry was kept")
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("leftover temporary file was kept: %v", err)
+	}
+}
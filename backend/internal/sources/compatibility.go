@@ -0,0 +1,137 @@
+package sources
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/util"
+)
+
+// Incompatibility is a mod that a mod version declares it cannot run with.
+// An empty VersionID means every version of the mod is incompatible.
+type Incompatibility struct {
+	ModID     string
+	ProjectID string
+	VersionID string
+}
+
+// IncompatibilityProvider is implemented by providers that know which mods
+// a mod version is incompatible with.
+type IncompatibilityProvider interface {
+	GetIncompatibilities(s *Settings, cfg *config.Config, modID, versionID string) ([]Incompatibility, error)
+}
+
+// Conflict is a pair of mods in a project that must not be used together.
+type Conflict struct {
+	ModID         string
+	ConflictsWith string
+	DeclaredBy    string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s conflicts with %s (declared by %s)", c.ModID, c.ConflictsWith, c.DeclaredBy)
+}
+
+// FindConflicts finds the conflicts of the given mods with every mod in the
+// project. Incompatibilities are usually declared by only one of the two
+// mods, so the declarations of the other project mods are checked against
+// the given mods as well. Mods whose incompatibilities cannot be looked up
+// are logged and skipped.
+func FindConflicts(s *Settings, cfg *config.Config, modIDs []string) []Conflict {
+	logger.Log.Printf("Checking compatibility of %d mods", len(modIDs))
+	checked := map[string]bool{}
+	for _, modID := range modIDs {
+		checked[modID] = true
+	}
+
+	processMod := func(modID string) []Conflict {
+		mod, ok := cfg.Mods[modID]
+		if !ok || mod.Platform == "" {
+			return nil
+		}
+		if mod.VersionID == "" {
+			logger.Log.Printf("Skipping compatibility check for mod %s without version ID", modID)
+			return nil
+		}
+		p, err := GetProvider(mod.Platform)
+		if err != nil {
+			return nil
+		}
+		ip, ok := p.(IncompatibilityProvider)
+		if !ok {
+			return nil
+		}
+
+		incompatibilities, err := ip.GetIncompatibilities(s, cfg, modID, mod.VersionID)
+		if err != nil {
+			logger.Log.Printf("Error getting incompatibilities for mod %s: %v", modID, err)
+			return nil
+		}
+
+		var conflicts []Conflict
+		for _, inc := range incompatibilities {
+			for otherID, other := range cfg.Mods {
+				if otherID == modID || !inc.matches(otherID, other) {
+					continue
+				}
+				// Conflicts between two mods that are not being checked
+				// were already there.
+				if !checked[modID] && !checked[otherID] {
+					continue
+				}
+				logger.Log.Printf("Mod %s is incompatible with %s", modID, otherID)
+				conflicts = append(conflicts, newConflict(modID, otherID, modID))
+			}
+		}
+		return conflicts
+	}
+
+	jobs := make(chan string, len(cfg.Mods))
+	results := util.WorkerPool(jobs, processMod, util.Workers(len(cfg.Mods)))
+
+	go func() {
+		for modID := range cfg.Mods {
+			jobs <- modID
+		}
+		close(jobs)
+	}()
+
+	seen := map[Conflict]bool{}
+	conflicts := make([]Conflict, 0)
+	for found := range results {
+		for _, c := range found {
+			key := Conflict{ModID: c.ModID, ConflictsWith: c.ConflictsWith}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	slices.SortFunc(conflicts, func(a, b Conflict) int {
+		return cmp.Or(strings.Compare(a.ModID, b.ModID), strings.Compare(a.ConflictsWith, b.ConflictsWith))
+	})
+	logger.Log.Printf("Found %d conflicts", len(conflicts))
+	return conflicts
+}
+
+func (inc Incompatibility) matches(modID string, mod config.Mod) bool {
+	if inc.ModID != modID && (inc.ProjectID == "" || inc.ProjectID != mod.ProjectID) {
+		return false
+	}
+	return inc.VersionID == "" || inc.VersionID == mod.VersionID
+}
+
+// newConflict orders the pair so the same conflict declared by both mods is
+// only reported once.
+func newConflict(a, b, declaredBy string) Conflict {
+	if b < a {
+		a, b = b, a
+	}
+	return Conflict{ModID: a, ConflictsWith: b, DeclaredBy: declaredBy}
+}
@@ -1,262 +1,599 @@
 package sources
 
 import (
+	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/sqot0/packsmith/backend/internal/config"
 	"github.com/sqot0/packsmith/backend/internal/logger"
-	"github.com/sqot0/packsmith/backend/internal/util"
 )
 
-func searchModsCurseforge(cfg *config.Config, query string) ([]ModSearch, error) {
-	logger.Log.Printf("Searching CurseForge for query: %s", query)
+func init() {
+	Register(curseforgeProvider{})
+}
+
+type curseforgeProvider struct{}
+
+func (curseforgeProvider) Name() string { return "curseforge" }
+
+func (curseforgeProvider) SearchMods(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	if s.curseforgeAPIEnabled() {
+		return searchModsCurseforgeAPI(s, cfg, query, opts)
+	}
+	return searchModsCurseforge(s, cfg, query, opts)
+}
+
+// The website is addressed by slug, so the scraper uses the key the mod is
+// stored under while the API uses the numeric project ID.
+
+func (curseforgeProvider) GetModVersions(s *Settings, cfg *config.Config, modID string) ([]ModVersion, error) {
+	if s.curseforgeAPIEnabled() {
+		return getModVersionsCurseforgeAPI(s, cfg, projectRef(cfg, modID))
+	}
+	return getModVersionsCurseforge(s, cfg, modID)
+}
+
+func (curseforgeProvider) GetVersion(s *Settings, cfg *config.Config, modID, versionID string) (ModVersion, error) {
+	if s.curseforgeAPIEnabled() {
+		return getVersionCurseforgeAPI(s, projectRef(cfg, modID), versionID)
+	}
+	return getVersionCurseforge(s, cfg, modID, versionID)
+}
+
+func (curseforgeProvider) GetDependencies(s *Settings, cfg *config.Config, modID, versionID string) ([]Dependency, error) {
+	if s.curseforgeAPIEnabled() {
+		return getDependenciesCurseforgeAPI(s, cfg, projectRef(cfg, modID), versionID)
+	}
+	return getDependenciesCurseforge(s, cfg, modID)
+}
+
+func (curseforgeProvider) GetLatestVersion(s *Settings, cfg *config.Config, modID, channel string) (ModVersion, error) {
+	return getLatestVersionCurseforge(s, cfg, modID, channel)
+}
+
+func (curseforgeProvider) GetModDetails(s *Settings, cfg *config.Config, modID string) (*ModDetails, error) {
+	if s.curseforgeAPIEnabled() {
+		return getModDetailsCurseforgeAPI(s, projectRef(cfg, modID))
+	}
+	return getModDetailsCurseforge(s, modID)
+}
+
+// IdentifyFiles matches files by their fingerprint. The website has no
+// fingerprint lookup, so files are only identified when the API is enabled.
+func (curseforgeProvider) IdentifyFiles(s *Settings, cfg *config.Config, files []FileHashes) (map[int]IdentifiedFile, error) {
+	if !s.curseforgeAPIEnabled() {
+		logger.Log.Println("Skipping CurseForge file identification, API key not set")
+		return nil, nil
+	}
+	return identifyFilesCurseforgeAPI(s, files)
+}
+
+var curseforgeSortBy = map[string]string{
+	SortRelevance: "relevancy",
+	SortDownloads: "total downloads",
+	SortFollows:   "popularity",
+	SortNewest:    "creation date",
+	SortUpdated:   "latest update",
+}
+
+// curseforgeCategoryIDs maps category names to CurseForge mod category IDs.
+// Modrinth categories without a CurseForge counterpart map to "" and are
+// left out of the filter.
+var curseforgeCategoryIDs = map[string]string{
+	"adventure":      "422",
+	"decoration":     "424",
+	"equipment":      "434",
+	"food":           "436",
+	"library":        "421",
+	"magic":          "419",
+	"management":     "435",
+	"mobs":           "411",
+	"optimization":   "6814",
+	"storage":        "420",
+	"technology":     "412",
+	"transportation": "414",
+	"utility":        "5191",
+	"worldgen":       "406",
+	"cursed":         "",
+	"economy":        "",
+	"game-mechanics": "",
+	"minigame":       "",
+	"social":         "",
+	// Categories only CurseForge has.
+	"biomes":     "407",
+	"dimensions": "410",
+	"energy":     "417",
+	"farming":    "416",
+	"redstone":   "4558",
+	"structures": "409",
+}
+
+// curseforgeCategories converts category names to CurseForge category IDs.
+// IDs are accepted as is.
+func curseforgeCategories(names []string) ([]string, error) {
+	var ids []string
+	for _, name := range names {
+		if _, err := strconv.Atoi(name); err == nil {
+			ids = append(ids, name)
+			continue
+		}
+		id, ok := curseforgeCategoryIDs[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown category %s", name)
+		}
+		if id == "" {
+			logger.Log.Printf("Category %s has no CurseForge counterpart, ignoring it", name)
+			continue
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func searchModsCurseforge(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	logger.Log.Printf("Searching CurseForge for query: %s, page: %d", query, opts.Page)
 	params := url.Values{}
-	params.Set("page", "1")
-	params.Set("pageSize", "5")
-	params.Set("sortBy", "relevancy")
+	params.Set("page", strconv.Itoa(opts.Page))
+	params.Set("pageSize", strconv.Itoa(opts.PageSize))
+	params.Set("sortBy", curseforgeSortBy[opts.Sort])
 	params.Set("class", "mc-mods")
+	categories, err := curseforgeCategories(opts.Categories)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) > 0 {
+		params.Set("categories", strings.Join(categories, ","))
+	}
 
 	params.Set("version", cfg.Minecraft)
 	params.Set("search", query)
-	switch cfg.Loader {
-	case "forge":
-		params.Set("gameVersionTypeId", "1")
-	case "fabric":
-		params.Set("gameVersionTypeId", "4")
-	case "neoforge":
-		params.Set("gameVersionTypeId", "6")
-	case "quilt":
-		params.Set("gameVersionTypeId", "5")
-	}
-
-	req, _ := http.NewRequest("GET", "https://www.curseforge.com/minecraft/search?"+params.Encode(), nil)
-	setHeadersForRequest(req)
-
-	logger.Log.Printf("Making HTTP request to CurseForge search")
-	resp, err := http.DefaultClient.Do(req)
+	// The website filters on a single loader, so with fallback loaders the
+	// loader filter is dropped and hits without a compatible file are
+	// removed below.
+	fallbacks := len(acceptedLoaders(cfg)) > 1
+	if loaderType := curseforgeModLoaderType(cfg.Loader); loaderType != "" && !fallbacks {
+		params.Set("gameVersionTypeId", loaderType)
+	}
+
+	doc, err := fetchCurseforgeDocument(s, s.CurseForgeURL+"/minecraft/search?"+params.Encode())
 	if err != nil {
-		logger.Log.Printf("Error making request: %v", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		logger.Log.Printf("CurseForge search returned status %d", resp.StatusCode)
-		return nil, fmt.Errorf("curseforge search returned status %d", resp.StatusCode)
+
+	var mods []ModSearch
+	for _, info := range parseCurseforgeProjectCards(doc) {
+		mods = append(mods, ModSearch{
+			ID:          info.id,
+			Name:        info.name,
+			Description: info.description,
+			Downloads:   info.downloads,
+			URL:         info.url,
+		})
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	err = fillVersions(mods, func(id string) ([]ModVersion, error) {
+		return getModVersionsCurseforge(s, cfg, id)
+	})
 	if err != nil {
-		logger.Log.Printf("Error parsing HTML: %v", err)
 		return nil, err
 	}
+	pageHits := len(mods)
+	if fallbacks {
+		mods = slices.DeleteFunc(mods, func(m ModSearch) bool { return len(m.Versions) == 0 })
+	}
+
+	// The website only shows the hit count as text; when it cannot be read,
+	// report the hits seen so far and one more page if this one was full.
+	total := opts.offset() + pageHits
+	if count, err := strconv.Atoi(strings.Map(keepDigits, doc.Find(".results-count").First().Text())); err == nil {
+		total = count
+	} else if pageHits == opts.PageSize {
+		total += opts.PageSize
+	}
 
-	type curseforgeModInfo struct {
-		id, name, description, downloads, url string
+	logger.Log.Printf("Found %d mods on CurseForge, %d total", len(mods), total)
+	return &SearchResult{Hits: mods, TotalHits: total, Page: opts.Page, PageSize: opts.PageSize}, nil
+}
+
+func keepDigits(r rune) rune {
+	if r >= '0' && r <= '9' {
+		return r
 	}
+	return -1
+}
 
+type curseforgeModInfo struct {
+	id, name, description, downloads, url string
+}
+
+func parseCurseforgeProjectCards(doc *goquery.Document) []curseforgeModInfo {
 	var modInfos []curseforgeModInfo
-	doc.Find(".project-card").Each(func(i int, s *goquery.Selection) {
-		modUrl := "https://www.curseforge.com" + s.Find(".name").AttrOr("href", "")
-		if modUrl == "" {
+	doc.Find(".project-card").Each(func(i int, sel *goquery.Selection) {
+		href := sel.Find(".name").AttrOr("href", "")
+		if href == "" {
 			return
 		}
+		modUrl := "https://www.curseforge.com" + href
 		id := strings.TrimPrefix(modUrl, "https://www.curseforge.com/minecraft/mc-mods/")
-		name := s.Find(".name span").Text()
-		description := s.Find(".description").Text()
-		downloads := s.Find(".details-list .detail-downloads").Text()
+		name := sel.Find(".name span").Text()
+		description := sel.Find(".description").Text()
+		downloads := sel.Find(".details-list .detail-downloads").Text()
 
 		modInfos = append(modInfos, curseforgeModInfo{
 			id: id, name: name, description: description, downloads: downloads, url: modUrl,
 		})
 	})
+	return modInfos
+}
 
-	var mods []ModSearch
-	var mu sync.Mutex
-	processMod := func(info curseforgeModInfo) error {
-		versions, err := getModVersionsCurseforge(cfg, info.id)
-		if err != nil {
-			logger.Log.Printf("Error getting version for mod %s: %v", info.id, err)
-			return err
-		}
-		mu.Lock()
-		mods = append(mods, ModSearch{
-			ID:          info.id,
-			Name:        info.name,
-			Description: info.description,
-			Downloads:   info.downloads,
-			URL:         info.url,
-			ClientSide:  "",
-			ServerSide:  "",
-			Versions:    versions,
-		})
-		mu.Unlock()
-		return nil
+func fetchCurseforgeDocument(s *Settings, pageURL string) (*goquery.Document, error) {
+	req, err := s.newRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	jobs := make(chan curseforgeModInfo, len(modInfos))
-	results := util.WorkerPool(jobs, processMod, len(modInfos))
-
-	go func() {
-		for _, info := range modInfos {
-			jobs <- info
-		}
-		close(jobs)
-	}()
-
-	for err := range results {
-		if err != nil {
-			return nil, err
-		}
+	logger.Log.Printf("Making HTTP request to CurseForge page: %s", pageURL)
+	resp, err := s.do(req)
+	if err != nil {
+		logger.Log.Printf("Error making request: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		logger.Log.Printf("CurseForge page returned status %d", resp.StatusCode)
+		return nil, statusError("curseforge", resp)
 	}
 
-	logger.Log.Printf("Found %d mods on CurseForge", len(mods))
-	return mods, nil
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		logger.Log.Printf("Error parsing HTML: %v", err)
+		return nil, err
+	}
+	return doc, nil
 }
 
-func getDownloadURLCurseforge(cfg *config.Config, id, version string) (string, error) {
-	logger.Log.Printf("Getting download URL for CurseForge mod: %s", id)
+// curseforgeFilesPageSize is the number of files the website lists per page.
+const curseforgeFilesPageSize = 20
+
+// fetchCurseforgeFiles scrapes one page of the files page of a project and
+// returns the files that are compatible with the project.
+func fetchCurseforgeFiles(s *Settings, cfg *config.Config, id string, page int) ([]ModVersion, error) {
 	params := url.Values{}
-	params.Set("page", "1")
-	params.Set("pageSize", "20")
-	params.Set("showAlphaFiles", "hide")
+	params.Set("page", strconv.Itoa(page))
+	params.Set("pageSize", strconv.Itoa(curseforgeFilesPageSize))
+	params.Set("showAlphaFiles", "show")
 	params.Set("class", "mc-mods")
 
 	params.Set("version", cfg.Minecraft)
-	switch cfg.Loader {
-	case "forge":
-		params.Set("gameVersionTypeId", "1")
-	case "fabric":
-		params.Set("gameVersionTypeId", "4")
-	case "neoforge":
-		params.Set("gameVersionTypeId", "6")
-	case "quilt":
-		params.Set("gameVersionTypeId", "5")
-	}
-
-	searchUrl := fmt.Sprintf("https://www.curseforge.com/minecraft/mc-mods/%s/files/all?", id)
-	req, _ := http.NewRequest("GET", searchUrl+params.Encode(), nil)
-	setHeadersForRequest(req)
-
-	logger.Log.Printf("Making HTTP request to CurseForge files page")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		logger.Log.Printf("Error making request: %v", err)
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		logger.Log.Printf("CurseForge files page returned status %d", resp.StatusCode)
-		return "", fmt.Errorf("curseforge search returned status %d", resp.StatusCode)
+	// Files for fallback loaders are filtered by walkCurseforgeFiles instead,
+	// the website filters on a single loader.
+	if loaderType := curseforgeModLoaderType(cfg.Loader); loaderType != "" && len(acceptedLoaders(cfg)) == 1 {
+		params.Set("gameVersionTypeId", loaderType)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	filesUrl := fmt.Sprintf("%s/minecraft/mc-mods/%s/files/all?", s.CurseForgeURL, id)
+	doc, err := fetchCurseforgeDocument(s, filesUrl+params.Encode())
 	if err != nil {
-		logger.Log.Printf("Error parsing HTML: %v", err)
-		return "", err
+		return nil, err
 	}
 
 	projectId := doc.Find(".project-id").Text()
 	if projectId == "" {
 		logger.Log.Println("Could not find project ID")
-		return "", fmt.Errorf("could not find project ID")
-	}
-
-	var downloadUrl string
-	found := false
-	doc.Find(".file-row-details").Each(func(i int, s *goquery.Selection) {
-		title := s.Find("span.name").AttrOr("title", "")
-		if title == version {
-			fileDetailsURL := s.AttrOr("href", "")
-			if fileDetailsURL != "" {
-				fileId := fileDetailsURL[strings.LastIndex(fileDetailsURL, "/")+1:]
-				downloadUrl = fmt.Sprintf("https://www.curseforge.com/api/v1/mods/%s/files/%s/download", projectId, fileId)
-				found = true
-				return
+		return nil, fmt.Errorf("could not find project ID")
+	}
+
+	var files []ModVersion
+	doc.Find(".file-row-details").Each(func(i int, sel *goquery.Selection) {
+		version := sel.Find("span.name").AttrOr("title", "")
+		fileDetailsURL := sel.AttrOr("href", "")
+		if version == "" || fileDetailsURL == "" {
+			return
+		}
+		fileId := fileDetailsURL[strings.LastIndex(fileDetailsURL, "/")+1:]
+		row := sel.Closest(".file-row")
+		if row.Length() == 0 {
+			row = sel
+		}
+
+		gameVersions, loaders := splitCurseforgeGameVersions(strings.Fields(row.Find(".game-version").Text()))
+		files = append(files, ModVersion{
+			ID:           fileId,
+			ProjectID:    projectId,
+			Version:      version,
+			Channel:      curseforgeChannel(strings.TrimSpace(row.Find(".channel-tag").Text())),
+			Published:    row.Find(".upload-date").AttrOr("data-date", strings.TrimSpace(row.Find(".upload-date").Text())),
+			FileName:     version,
+			FileSize:     parseFileSize(row.Find(".file-size").Text()),
+			GameVersions: gameVersions,
+			Loaders:      loaders,
+			DownloadURL:  fmt.Sprintf("%s/api/v1/mods/%s/files/%s/download", s.CurseForgeURL, projectId, fileId),
+		})
+	})
+
+	logger.Log.Printf("Found %d files on page %d for CurseForge mod: %s", len(files), page, id)
+	return files, nil
+}
+
+// walkCurseforgeFiles scrapes the files pages of a project, newest first,
+// and passes the compatible files of each page to visit until it returns
+// true or the last page has been read.
+func walkCurseforgeFiles(s *Settings, cfg *config.Config, id string, visit func([]ModVersion) bool) error {
+	var previous string
+	for page := 1; ; page++ {
+		files, err := fetchCurseforgeFiles(s, cfg, id, page)
+		if err != nil {
+			return err
+		}
+		// The website serves the last page again for pages past the end.
+		if len(files) == 0 || files[0].ID == previous {
+			return nil
+		}
+		if visit(compatibleLoaders(cfg, files)) || len(files) < curseforgeFilesPageSize {
+			return nil
+		}
+		previous = files[0].ID
+	}
+}
+
+func getVersionCurseforge(s *Settings, cfg *config.Config, id, versionID string) (ModVersion, error) {
+	logger.Log.Printf("Getting file %s for CurseForge mod: %s", versionID, id)
+	var found *ModVersion
+	err := walkCurseforgeFiles(s, cfg, id, func(files []ModVersion) bool {
+		for _, f := range files {
+			if f.ID == versionID {
+				found = &f
+				return true
 			}
 		}
+		return false
 	})
+	if err != nil {
+		return ModVersion{}, err
+	}
 
-	if !found {
-		logger.Log.Printf("Could not find file for version: %s", version)
-		return "", fmt.Errorf("could not find file for version: %s", version)
+	if found == nil {
+		logger.Log.Printf("Could not find file: %s", versionID)
+		return ModVersion{}, fmt.Errorf("could not find file: %s", versionID)
 	}
+	logger.Log.Printf("Download URL obtained: %s, version: %s", found.DownloadURL, found.Version)
+	return *found, nil
+}
 
-	logger.Log.Printf("Download URL obtained: %s, version: %s", downloadUrl)
-	return downloadUrl, nil
+func getModVersionsCurseforge(s *Settings, cfg *config.Config, id string) ([]ModVersion, error) {
+	var versions []ModVersion
+	err := walkCurseforgeFiles(s, cfg, id, func(files []ModVersion) bool {
+		versions = append(versions, files...)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		logger.Log.Println("Could not find file version")
+		return nil, fmt.Errorf("could not find file version")
+	}
+	logger.Log.Printf("Found %d files for CurseForge mod: %s", len(versions), id)
+	return versions, nil
 }
 
-func getModVersionsCurseforge(cfg *config.Config, id string) ([]string, error) {
-	params := url.Values{}
-	params.Set("page", "1")
-	params.Set("pageSize", "20")
-	params.Set("showAlphaFiles", "hide")
-	params.Set("class", "mc-mods")
+// getLatestVersionCurseforge reads files pages until one holds a file the
+// channel allows. The API and the website both list files newest first.
+func getLatestVersionCurseforge(s *Settings, cfg *config.Config, id, channel string) (ModVersion, error) {
+	walk := func(visit func([]ModVersion) bool) error {
+		return walkCurseforgeFiles(s, cfg, id, visit)
+	}
+	if s.curseforgeAPIEnabled() {
+		walk = func(visit func([]ModVersion) bool) error {
+			return walkCurseforgeAPIFiles(s, cfg, projectRef(cfg, id), visit)
+		}
+	}
 
-	params.Set("version", cfg.Minecraft)
-	switch cfg.Loader {
-	case "forge":
-		params.Set("gameVersionTypeId", "1")
-	case "fabric":
-		params.Set("gameVersionTypeId", "4")
-	case "neoforge":
-		params.Set("gameVersionTypeId", "6")
-	case "quilt":
-		params.Set("gameVersionTypeId", "5")
-	}
-
-	searchUrl := fmt.Sprintf("https://www.curseforge.com/minecraft/mc-mods/%s/files/all?", id)
-	req, _ := http.NewRequest("GET", searchUrl+params.Encode(), nil)
-	setHeadersForRequest(req)
-
-	logger.Log.Printf("Making HTTP request to CurseForge files page for version")
-	resp, err := http.DefaultClient.Do(req)
+	var seen []ModVersion
+	var latest ModVersion
+	var found bool
+	err := walk(func(files []ModVersion) bool {
+		seen = append(seen, files...)
+		latest, found = firstAllowed(cfg, seen, channel)
+		return found
+	})
 	if err != nil {
-		logger.Log.Printf("Error making request: %v", err)
-		return nil, err
+		return ModVersion{}, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		logger.Log.Printf("CurseForge files page returned status %d", resp.StatusCode)
-		return nil, fmt.Errorf("curseforge files page returned status %d", resp.StatusCode)
+	if !found {
+		logger.Log.Printf("No compatible %s version found", channel)
+		return ModVersion{}, fmt.Errorf("%w on the %s channel", ErrNoCompatibleVersion, channel)
 	}
+	logger.Log.Printf("Latest %s version found: %s", channel, latest.Version)
+	return latest, nil
+}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+// getModDetailsCurseforge scrapes the description page of a project. The
+// page header falls back to the Open Graph tags, which are present even
+// when the layout changes.
+func getModDetailsCurseforge(s *Settings, id string) (*ModDetails, error) {
+	logger.Log.Printf("Getting details for CurseForge mod: %s", id)
+	projectUrl := fmt.Sprintf("%s/minecraft/mc-mods/%s", s.CurseForgeURL, id)
+	doc, err := fetchCurseforgeDocument(s, projectUrl)
 	if err != nil {
-		logger.Log.Printf("Error parsing HTML: %v", err)
 		return nil, err
 	}
 
-	fileVersions := doc.Find("span.name")
-	if fileVersions.Length() == 0 {
-		logger.Log.Println("Could not find file version")
-		return nil, fmt.Errorf("could not find file version")
+	firstText := func(selectors ...string) string {
+		for _, sel := range selectors {
+			if text := strings.TrimSpace(doc.Find(sel).First().Text()); text != "" {
+				return text
+			}
+		}
+		return ""
+	}
+	meta := func(property string) string {
+		return doc.Find(`meta[property="`+property+`"]`).AttrOr("content", "")
+	}
+
+	details := &ModDetails{
+		ID:          id,
+		ProjectID:   strings.TrimSpace(doc.Find(".project-id").First().Text()),
+		Name:        firstText(".project-header h1", "h1"),
+		Description: meta("og:description"),
+		IconURL:     doc.Find(".project-header img").First().AttrOr("src", meta("og:image")),
+		License:     firstText(".project-license", ".license"),
+		URL:         "https://www.curseforge.com/minecraft/mc-mods/" + id,
+		Updated:     doc.Find(".detail-updated time, .project-details time").First().AttrOr("datetime", ""),
+		BodyFormat:  BodyHTML,
+	}
+	if details.Name == "" {
+		details.Name = meta("og:title")
+	}
+	if downloads, err := strconv.ParseInt(strings.ReplaceAll(firstText(".detail-downloads"), ",", ""), 10, 64); err == nil {
+		details.Downloads = downloads
+	}
+	if body, err := doc.Find(".project-description").First().Html(); err == nil {
+		details.Body = strings.TrimSpace(body)
 	}
 
-	var result []string
-	fileVersions.Each(func(i int, s *goquery.Selection) {
-		version := s.AttrOr("title", "")
-		if version != "" {
-			result = append(result, version)
+	doc.Find(".author-name, .project-authors a").Each(func(i int, sel *goquery.Selection) {
+		if name := strings.TrimSpace(sel.Text()); name != "" && !slices.Contains(details.Authors, name) {
+			details.Authors = append(details.Authors, name)
+		}
+	})
+	doc.Find(".project-categories a, .categories a").Each(func(i int, sel *goquery.Selection) {
+		name := strings.TrimSpace(sel.AttrOr("title", sel.Text()))
+		if name != "" && !slices.Contains(details.Categories, name) {
+			details.Categories = append(details.Categories, name)
+		}
+	})
+	doc.Find(".project-links a, .project-details a").Each(func(i int, sel *goquery.Selection) {
+		href := sel.AttrOr("href", "")
+		label := strings.ToLower(sel.Text())
+		switch {
+		case strings.Contains(label, "source"):
+			details.SourceURL = href
+		case strings.Contains(label, "issue"):
+			details.IssuesURL = href
+		case strings.Contains(label, "wiki"):
+			details.WikiURL = href
+		}
+	})
+	doc.Find(".project-gallery img, .gallery img").Each(func(i int, sel *goquery.Selection) {
+		if src := sel.AttrOr("src", ""); src != "" {
+			details.Gallery = append(details.Gallery, GalleryImage{URL: src, Title: sel.AttrOr("alt", "")})
 		}
 	})
 
-	return result, nil
+	if details.Name == "" {
+		logger.Log.Printf("Could not read project page of CurseForge mod: %s", id)
+		return nil, fmt.Errorf("could not read curseforge project page: %s", id)
+	}
+	logger.Log.Printf("Got details for CurseForge mod: %s", id)
+	return details, nil
 }
 
-func getLatestVersionCurseforge(cfg *config.Config, id string) (string, error) {
-	versions, err := getModVersionsCurseforge(cfg, id)
+// getDependenciesCurseforge reads the required dependencies from the
+// project's relations page. The website only lists relations of the project
+// as a whole, so the latest compatible file of each dependency allowed by the
+// project channel is used. A dependency without such a file is returned
+// unresolved.
+func getDependenciesCurseforge(s *Settings, cfg *config.Config, id string) ([]Dependency, error) {
+	logger.Log.Printf("Getting dependencies for CurseForge mod: %s", id)
+	params := url.Values{}
+	params.Set("type", "RequiredDependency")
+	relationsUrl := fmt.Sprintf("%s/minecraft/mc-mods/%s/relations/dependencies?", s.CurseForgeURL, id)
+
+	doc, err := fetchCurseforgeDocument(s, relationsUrl+params.Encode())
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	if len(versions) == 0 {
-		logger.Log.Println("No versions found for mod")
-		return "", fmt.Errorf("no versions found for mod")
+
+	var deps []Dependency
+	for _, info := range parseCurseforgeProjectCards(doc) {
+		version, err := getLatestVersionCurseforge(s, cfg, info.id, cfg.ModChannel(config.Mod{}))
+		if errors.Is(err, ErrNoCompatibleVersion) {
+			logger.Log.Printf("No compatible version found for dependency: %s", info.id)
+			deps = append(deps, Dependency{
+				ModID:      info.id,
+				Name:       info.name,
+				Source:     info.url,
+				Unresolved: ErrNoCompatibleVersion.Error(),
+			})
+			continue
+		}
+		if err != nil {
+			logger.Log.Printf("Error getting latest version for dependency %s: %v", info.id, err)
+			return nil, fmt.Errorf("%s: %w", info.id, err)
+		}
+		deps = append(deps, Dependency{
+			ModID:     info.id,
+			ProjectID: version.ProjectID,
+			Name:      info.name,
+			Version:   version.Version,
+			VersionID: version.ID,
+			FileName:  version.FileName,
+			URL:       version.DownloadURL,
+			Source:    info.url,
+		})
+	}
+	logger.Log.Printf("Found %d required dependencies for mod: %s", len(deps), id)
+	return deps, nil
+}
+
+func curseforgeChannel(tag string) string {
+	switch strings.ToLower(tag) {
+	case "b", "beta":
+		return ChannelBeta
+	case "a", "alpha":
+		return ChannelAlpha
 	}
+	return ChannelRelease
+}
 
-	return versions[0], nil
+var curseforgeLoaders = map[string]string{
+	"forge":    "forge",
+	"fabric":   "fabric",
+	"neoforge": "neoforge",
+	"quilt":    "quilt",
+}
+
+// splitCurseforgeGameVersions separates loaders from Minecraft versions.
+// CurseForge lists both, along with environments and Java versions, as
+// game versions.
+func splitCurseforgeGameVersions(values []string) (gameVersions, loaders []string) {
+	for _, v := range values {
+		if loader, ok := curseforgeLoaders[strings.ToLower(v)]; ok {
+			loaders = append(loaders, loader)
+		} else if v != "" && v[0] >= '0' && v[0] <= '9' {
+			gameVersions = append(gameVersions, v)
+		}
+	}
+	return gameVersions, loaders
+}
+
+// parseFileSize converts sizes such as "1.25 MB" to bytes.
+func parseFileSize(text string) int64 {
+	fields := strings.Fields(strings.ReplaceAll(text, ",", ""))
+	if len(fields) == 0 {
+		return 0
+	}
+	size, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	if len(fields) > 1 {
+		switch strings.ToUpper(fields[1]) {
+		case "KB":
+			size *= 1 << 10
+		case "MB":
+			size *= 1 << 20
+		case "GB":
+			size *= 1 << 30
+		}
+	}
+	return int64(size)
 }
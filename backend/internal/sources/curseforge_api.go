@@ -0,0 +1,555 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+const (
+	curseforgeMinecraftGameID = "432"
+	curseforgeModsClassID     = "6"
+
+	curseforgeRequiredDependency = 3
+)
+
+// curseforgeAPIIDs caches the numeric project IDs resolved from slugs.
+var curseforgeAPIIDs = struct {
+	sync.RWMutex
+	ids map[string]int
+}{ids: map[string]int{}}
+
+type CurseforgeAPIMod struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	Slug          string  `json:"slug"`
+	Summary       string  `json:"summary"`
+	DownloadCount float64 `json:"downloadCount"`
+	Links         struct {
+		WebsiteURL string `json:"websiteUrl"`
+		WikiURL    string `json:"wikiUrl"`
+		IssuesURL  string `json:"issuesUrl"`
+		SourceURL  string `json:"sourceUrl"`
+	} `json:"links"`
+	Logo struct {
+		URL          string `json:"url"`
+		ThumbnailURL string `json:"thumbnailUrl"`
+	} `json:"logo"`
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Categories []struct {
+		Name string `json:"name"`
+	} `json:"categories"`
+	Screenshots []struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		URL         string `json:"url"`
+	} `json:"screenshots"`
+	ThumbsUpCount int64  `json:"thumbsUpCount"`
+	DateModified  string `json:"dateModified"`
+}
+
+type CurseforgeAPIFile struct {
+	ID           int      `json:"id"`
+	ModID        int      `json:"modId"`
+	DisplayName  string   `json:"displayName"`
+	FileName     string   `json:"fileName"`
+	ReleaseType  int      `json:"releaseType"`
+	FileDate     string   `json:"fileDate"`
+	FileLength   int64    `json:"fileLength"`
+	DownloadURL  string   `json:"downloadUrl"`
+	GameVersions []string `json:"gameVersions"`
+	Fingerprint  uint32   `json:"fileFingerprint"`
+	Dependencies []struct {
+		ModID        int `json:"modId"`
+		RelationType int `json:"relationType"`
+	} `json:"dependencies"`
+}
+
+func (f CurseforgeAPIFile) toModVersion(s *Settings) ModVersion {
+	gameVersions, loaders := splitCurseforgeGameVersions(f.GameVersions)
+	channel := ChannelRelease
+	switch f.ReleaseType {
+	case 2:
+		channel = ChannelBeta
+	case 3:
+		channel = ChannelAlpha
+	}
+	return ModVersion{
+		ID:           strconv.Itoa(f.ID),
+		ProjectID:    strconv.Itoa(f.ModID),
+		Version:      f.DisplayName,
+		Channel:      channel,
+		Published:    f.FileDate,
+		FileName:     f.FileName,
+		FileSize:     f.FileLength,
+		GameVersions: gameVersions,
+		Loaders:      loaders,
+		DownloadURL:  curseforgeAPIDownloadURL(s, f),
+	}
+}
+
+type curseforgeAPIPagination struct {
+	Index       int `json:"index"`
+	PageSize    int `json:"pageSize"`
+	ResultCount int `json:"resultCount"`
+	TotalCount  int `json:"totalCount"`
+}
+
+func curseforgeAPIGet(s *Settings, endpoint string, params url.Values, out any) error {
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	return curseforgeAPIDo(s, "GET", endpoint, nil, out)
+}
+
+func curseforgeAPIPost(s *Settings, endpoint string, body, out any) error {
+	return curseforgeAPIDo(s, "POST", endpoint, body, out)
+}
+
+func curseforgeAPIDo(s *Settings, method, endpoint string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			logger.Log.Printf("Error encoding request body: %v", err)
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := s.newRequest(method, strings.TrimRight(s.CurseForgeAPIURL, "/")+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-api-key", s.CurseForgeAPIKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	logger.Log.Printf("Making HTTP request to CurseForge API: %s", endpoint)
+	resp, err := s.do(req)
+	if err != nil {
+		logger.Log.Printf("Error making request: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.Log.Printf("CurseForge API returned status %d", resp.StatusCode)
+		return statusError("curseforge api", resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		logger.Log.Printf("Error decoding JSON response: %v", err)
+		return err
+	}
+	return nil
+}
+
+func curseforgeModLoaderType(loader string) string {
+	switch loader {
+	case "forge":
+		return "1"
+	case "fabric":
+		return "4"
+	case "quilt":
+		return "5"
+	case "neoforge":
+		return "6"
+	}
+	return ""
+}
+
+func curseforgeModLoaderTypes(loaders []string) []string {
+	var types []string
+	for _, l := range loaders {
+		if t := curseforgeModLoaderType(l); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func curseforgeAPIModURL(mod CurseforgeAPIMod) string {
+	if mod.Links.WebsiteURL != "" {
+		return mod.Links.WebsiteURL
+	}
+	return "https://www.curseforge.com/minecraft/mc-mods/" + mod.Slug
+}
+
+// curseforgeAPIModID resolves a CurseForge slug to the numeric project ID
+// used by the API. Numeric IDs are returned as is.
+func curseforgeAPIModID(s *Settings, slug string) (int, error) {
+	if id, err := strconv.Atoi(slug); err == nil {
+		return id, nil
+	}
+
+	curseforgeAPIIDs.RLock()
+	id, ok := curseforgeAPIIDs.ids[slug]
+	curseforgeAPIIDs.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	logger.Log.Printf("Resolving CurseForge project ID for slug: %s", slug)
+	params := url.Values{
+		"gameId":  {curseforgeMinecraftGameID},
+		"classId": {curseforgeModsClassID},
+		"slug":    {slug},
+	}
+	var data struct {
+		Data []CurseforgeAPIMod `json:"data"`
+	}
+	if err := curseforgeAPIGet(s, "/v1/mods/search", params, &data); err != nil {
+		return 0, err
+	}
+	for _, mod := range data.Data {
+		if mod.Slug == slug {
+			curseforgeAPIIDs.Lock()
+			curseforgeAPIIDs.ids[slug] = mod.ID
+			curseforgeAPIIDs.Unlock()
+			return mod.ID, nil
+		}
+	}
+
+	logger.Log.Printf("Could not find CurseForge project for slug: %s", slug)
+	return 0, fmt.Errorf("could not find curseforge project: %s", slug)
+}
+
+var curseforgeAPISortField = map[string]string{
+	SortRelevance: "1",
+	SortDownloads: "6",
+	SortFollows:   "2",
+	SortNewest:    "11",
+	SortUpdated:   "3",
+}
+
+func searchModsCurseforgeAPI(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	logger.Log.Printf("Searching CurseForge API for query: %s, page: %d", query, opts.Page)
+	params := url.Values{
+		"gameId":       {curseforgeMinecraftGameID},
+		"classId":      {curseforgeModsClassID},
+		"searchFilter": {query},
+		"gameVersion":  {cfg.Minecraft},
+		"sortField":    {curseforgeAPISortField[opts.Sort]},
+		"sortOrder":    {"desc"},
+		"index":        {strconv.Itoa(opts.offset())},
+		"pageSize":     {strconv.Itoa(opts.PageSize)},
+	}
+	if loaderTypes := curseforgeModLoaderTypes(acceptedLoaders(cfg)); len(loaderTypes) == 1 {
+		params.Set("modLoaderType", loaderTypes[0])
+	} else if len(loaderTypes) > 1 {
+		params.Set("modLoaderTypes", "["+strings.Join(loaderTypes, ",")+"]")
+	}
+	categories, err := curseforgeCategories(opts.Categories)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) > 0 {
+		params.Set("categoryIds", "["+strings.Join(categories, ",")+"]")
+	}
+
+	var data struct {
+		Data       []CurseforgeAPIMod      `json:"data"`
+		Pagination curseforgeAPIPagination `json:"pagination"`
+	}
+	if err := curseforgeAPIGet(s, "/v1/mods/search", params, &data); err != nil {
+		return nil, err
+	}
+
+	mods := make([]ModSearch, 0, len(data.Data))
+	curseforgeAPIIDs.Lock()
+	for _, mod := range data.Data {
+		curseforgeAPIIDs.ids[mod.Slug] = mod.ID
+		mods = append(mods, ModSearch{
+			ID:          mod.Slug,
+			Name:        mod.Name,
+			Description: mod.Summary,
+			Downloads:   strconv.FormatInt(int64(mod.DownloadCount), 10),
+			URL:         curseforgeAPIModURL(mod),
+		})
+	}
+	curseforgeAPIIDs.Unlock()
+
+	err = fillVersions(mods, func(id string) ([]ModVersion, error) {
+		return getModVersionsCurseforgeAPI(s, cfg, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Printf("Found %d mods on CurseForge API, %d total", len(mods), data.Pagination.TotalCount)
+	return &SearchResult{Hits: mods, TotalHits: data.Pagination.TotalCount, Page: opts.Page, PageSize: opts.PageSize}, nil
+}
+
+// curseforgeAPIFilesPageSize is the number of files requested per page,
+// the API allows at most 50.
+const curseforgeAPIFilesPageSize = 50
+
+// walkCurseforgeAPIFiles lists the files of a project page by page, newest
+// first, and passes the compatible files of each page to visit until it
+// returns true or the last page has been read. The files endpoint filters on
+// a single loader, so fallback loaders are filtered here.
+func walkCurseforgeAPIFiles(s *Settings, cfg *config.Config, id string, visit func([]ModVersion) bool) error {
+	modID, err := curseforgeAPIModID(s, id)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{
+		"gameVersion": {cfg.Minecraft},
+		"pageSize":    {strconv.Itoa(curseforgeAPIFilesPageSize)},
+	}
+	if loaderType := curseforgeModLoaderType(cfg.Loader); loaderType != "" && len(acceptedLoaders(cfg)) == 1 {
+		params.Set("modLoaderType", loaderType)
+	}
+
+	for index := 0; ; index += curseforgeAPIFilesPageSize {
+		params.Set("index", strconv.Itoa(index))
+		var data struct {
+			Data       []CurseforgeAPIFile     `json:"data"`
+			Pagination curseforgeAPIPagination `json:"pagination"`
+		}
+		if err := curseforgeAPIGet(s, fmt.Sprintf("/v1/mods/%d/files", modID), params, &data); err != nil {
+			return err
+		}
+		logger.Log.Printf("Found %d files at index %d for CurseForge mod: %s", len(data.Data), index, id)
+
+		versions := make([]ModVersion, 0, len(data.Data))
+		for _, f := range data.Data {
+			versions = append(versions, f.toModVersion(s))
+		}
+		if visit(compatibleLoaders(cfg, versions)) || len(data.Data) < curseforgeAPIFilesPageSize || index+len(data.Data) >= data.Pagination.TotalCount {
+			return nil
+		}
+	}
+}
+
+func curseforgeAPIDownloadURL(s *Settings, file CurseforgeAPIFile) string {
+	if file.DownloadURL != "" {
+		return file.DownloadURL
+	}
+	// Projects that opt out of third-party distribution have no downloadUrl,
+	// the website download endpoint still serves those files.
+	return fmt.Sprintf("%s/api/v1/mods/%d/files/%d/download", s.CurseForgeURL, file.ModID, file.ID)
+}
+
+func getFileCurseforgeAPI(s *Settings, id, fileID string) (CurseforgeAPIFile, error) {
+	modID, err := curseforgeAPIModID(s, id)
+	if err != nil {
+		return CurseforgeAPIFile{}, err
+	}
+
+	var data struct {
+		Data CurseforgeAPIFile `json:"data"`
+	}
+	if err := curseforgeAPIGet(s, fmt.Sprintf("/v1/mods/%d/files/%s", modID, url.PathEscape(fileID)), nil, &data); err != nil {
+		return CurseforgeAPIFile{}, err
+	}
+	return data.Data, nil
+}
+
+func getVersionCurseforgeAPI(s *Settings, id, fileID string) (ModVersion, error) {
+	logger.Log.Printf("Getting file %s from CurseForge API for mod: %s", fileID, id)
+	file, err := getFileCurseforgeAPI(s, id, fileID)
+	if err != nil {
+		return ModVersion{}, err
+	}
+	version := file.toModVersion(s)
+	logger.Log.Printf("Download URL obtained: %s, version: %s", version.DownloadURL, version.Version)
+	return version, nil
+}
+
+func getModVersionsCurseforgeAPI(s *Settings, cfg *config.Config, id string) ([]ModVersion, error) {
+	var versions []ModVersion
+	err := walkCurseforgeAPIFiles(s, cfg, id, func(files []ModVersion) bool {
+		versions = append(versions, files...)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Log.Printf("Found %d files for CurseForge mod: %s", len(versions), id)
+	return versions, nil
+}
+
+func getDependenciesCurseforgeAPI(s *Settings, cfg *config.Config, id, fileID string) ([]Dependency, error) {
+	logger.Log.Printf("Getting dependencies from CurseForge API for mod: %s, file: %s", id, fileID)
+	file, err := getFileCurseforgeAPI(s, id, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []Dependency
+	for _, d := range file.Dependencies {
+		if d.RelationType != curseforgeRequiredDependency {
+			continue
+		}
+
+		var data struct {
+			Data CurseforgeAPIMod `json:"data"`
+		}
+		if err := curseforgeAPIGet(s, fmt.Sprintf("/v1/mods/%d", d.ModID), nil, &data); err != nil {
+			return nil, err
+		}
+		mod := data.Data
+		curseforgeAPIIDs.Lock()
+		curseforgeAPIIDs.ids[mod.Slug] = mod.ID
+		curseforgeAPIIDs.Unlock()
+
+		depVersions, err := getModVersionsCurseforgeAPI(s, cfg, mod.Slug)
+		if err != nil {
+			return nil, err
+		}
+		channel := cfg.ModChannel(config.Mod{})
+		depVersion, ok := firstAllowed(cfg, depVersions, channel)
+		dep := Dependency{
+			ModID:     mod.Slug,
+			ProjectID: strconv.Itoa(mod.ID),
+			Name:      mod.Name,
+			Source:    curseforgeAPIModURL(mod),
+		}
+		if !ok {
+			logger.Log.Printf("No compatible files found for dependency: %s", mod.Slug)
+			dep.Unresolved = ErrNoCompatibleVersion.Error()
+			deps = append(deps, dep)
+			continue
+		}
+
+		dep.Version = depVersion.Version
+		dep.VersionID = depVersion.ID
+		dep.FileName = depVersion.FileName
+		dep.URL = depVersion.DownloadURL
+		deps = append(deps, dep)
+	}
+	logger.Log.Printf("Found %d required dependencies for mod: %s", len(deps), id)
+	return deps, nil
+}
+
+func identifyFilesCurseforgeAPI(s *Settings, files []FileHashes) (map[int]IdentifiedFile, error) {
+	byFingerprint := map[uint32]int{}
+	fingerprints := make([]uint32, 0, len(files))
+	for i, f := range files {
+		byFingerprint[f.Murmur2] = i
+		fingerprints = append(fingerprints, f.Murmur2)
+	}
+
+	logger.Log.Printf("Looking up %d fingerprints on CurseForge API", len(fingerprints))
+	var matches struct {
+		Data struct {
+			ExactMatches []struct {
+				ID   int               `json:"id"`
+				File CurseforgeAPIFile `json:"file"`
+			} `json:"exactMatches"`
+		} `json:"data"`
+	}
+	body := map[string]any{"fingerprints": fingerprints}
+	if err := curseforgeAPIPost(s, "/v1/fingerprints/"+curseforgeMinecraftGameID, body, &matches); err != nil {
+		return nil, err
+	}
+	if len(matches.Data.ExactMatches) == 0 {
+		return nil, nil
+	}
+
+	modIDs := make([]int, 0, len(matches.Data.ExactMatches))
+	for _, m := range matches.Data.ExactMatches {
+		modIDs = append(modIDs, m.ID)
+	}
+	var mods struct {
+		Data []CurseforgeAPIMod `json:"data"`
+	}
+	if err := curseforgeAPIPost(s, "/v1/mods", map[string]any{"modIds": modIDs}, &mods); err != nil {
+		return nil, err
+	}
+
+	identified := map[int]IdentifiedFile{}
+	for _, m := range matches.Data.ExactMatches {
+		i, ok := byFingerprint[m.File.Fingerprint]
+		if !ok {
+			continue
+		}
+		idx := slices.IndexFunc(mods.Data, func(mod CurseforgeAPIMod) bool { return mod.ID == m.ID })
+		if idx == -1 {
+			logger.Log.Printf("Could not find CurseForge project: %d", m.ID)
+			continue
+		}
+		mod := mods.Data[idx]
+		curseforgeAPIIDs.Lock()
+		curseforgeAPIIDs.ids[mod.Slug] = mod.ID
+		curseforgeAPIIDs.Unlock()
+		identified[i] = IdentifiedFile{
+			ModID:    mod.Slug,
+			Name:     mod.Name,
+			Platform: "curseforge",
+			Source:   curseforgeAPIModURL(mod),
+			// CurseForge does not record sides, jars also published on
+			// Modrinth were already identified there with their side.
+			Side:    "both",
+			Version: m.File.toModVersion(s),
+		}
+	}
+	return identified, nil
+}
+
+// getModDetailsCurseforgeAPI describes a mod from the API. The API does not
+// expose licenses or followers, thumbs up are reported as followers.
+func getModDetailsCurseforgeAPI(s *Settings, id string) (*ModDetails, error) {
+	logger.Log.Printf("Getting details from CurseForge API for mod: %s", id)
+	modID, err := curseforgeAPIModID(s, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var mod struct {
+		Data CurseforgeAPIMod `json:"data"`
+	}
+	if err := curseforgeAPIGet(s, fmt.Sprintf("/v1/mods/%d", modID), nil, &mod); err != nil {
+		return nil, err
+	}
+	var description struct {
+		Data string `json:"data"`
+	}
+	if err := curseforgeAPIGet(s, fmt.Sprintf("/v1/mods/%d/description", modID), nil, &description); err != nil {
+		return nil, err
+	}
+
+	m := mod.Data
+	details := &ModDetails{
+		ID:          m.Slug,
+		ProjectID:   strconv.Itoa(m.ID),
+		Name:        m.Name,
+		Description: m.Summary,
+		IconURL:     m.Logo.ThumbnailURL,
+		URL:         curseforgeAPIModURL(m),
+		SourceURL:   m.Links.SourceURL,
+		IssuesURL:   m.Links.IssuesURL,
+		WikiURL:     m.Links.WikiURL,
+		Downloads:   int64(m.DownloadCount),
+		Followers:   m.ThumbsUpCount,
+		Updated:     m.DateModified,
+		Body:        description.Data,
+		BodyFormat:  BodyHTML,
+	}
+	for _, a := range m.Authors {
+		details.Authors = append(details.Authors, a.Name)
+	}
+	for _, c := range m.Categories {
+		details.Categories = append(details.Categories, c.Name)
+	}
+	for _, img := range m.Screenshots {
+		details.Gallery = append(details.Gallery, GalleryImage{URL: img.URL, Title: img.Title, Description: img.Description})
+	}
+	logger.Log.Printf("Got details from CurseForge API for mod: %s", id)
+	return details, nil
+}
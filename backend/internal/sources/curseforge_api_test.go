@@ -0,0 +1,285 @@
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+)
+
+const curseforgeTestKey = "test-key"
+
+func curseforgeTestFile(id, releaseType int, gameVersions ...string) CurseforgeAPIFile {
+	return CurseforgeAPIFile{
+		ID:           id,
+		ModID:        238222,
+		DisplayName:  fmt.Sprintf("jei-%d", id),
+		FileName:     fmt.Sprintf("jei-%d.jar", id),
+		ReleaseType:  releaseType,
+		FileLength:   2048,
+		DownloadURL:  fmt.Sprintf("https://edge.forgecdn.net/files/%d/jei.jar", id),
+		GameVersions: gameVersions,
+		Fingerprint:  uint32(id),
+	}
+}
+
+// curseforgeTestServer fakes the CurseForge API for a project whose files
+// are served newest first, in pages of at most 50.
+type curseforgeTestServer struct {
+	*httptest.Server
+	files []CurseforgeAPIFile
+
+	mu            sync.Mutex
+	loaderTypes   []string
+	searchedSlugs []string
+}
+
+// requests returns the modLoaderType of every files request and the slugs
+// that were looked up.
+func (cs *curseforgeTestServer) requests() (loaderTypes, searchedSlugs []string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return slices.Clone(cs.loaderTypes), slices.Clone(cs.searchedSlugs)
+}
+
+func newCurseforgeTestServer(t *testing.T, slug string, files []CurseforgeAPIFile) *curseforgeTestServer {
+	t.Helper()
+	cs := &curseforgeTestServer{files: files}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/mods/search", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("gameId") != curseforgeMinecraftGameID || q.Get("classId") != curseforgeModsClassID {
+			t.Errorf("search parameters = %v", q)
+		}
+		cs.mu.Lock()
+		cs.searchedSlugs = append(cs.searchedSlugs, q.Get("slug"))
+		cs.mu.Unlock()
+		writeJSON(t, w, map[string]any{"data": []map[string]any{
+			{"id": 1, "slug": slug + "-addon"},
+			{"id": 238222, "slug": slug, "name": "Just Enough Items"},
+		}})
+	})
+	mux.HandleFunc("GET /v1/mods/238222/files", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("gameVersion") != "1.20.1" || q.Get("pageSize") != strconv.Itoa(curseforgeAPIFilesPageSize) {
+			t.Errorf("files parameters = %v", q)
+		}
+		cs.mu.Lock()
+		cs.loaderTypes = append(cs.loaderTypes, q.Get("modLoaderType"))
+		cs.mu.Unlock()
+		index, _ := strconv.Atoi(q.Get("index"))
+		end := min(index+curseforgeAPIFilesPageSize, len(cs.files))
+		page := cs.files[min(index, end):end]
+		writeJSON(t, w, map[string]any{
+			"data":       page,
+			"pagination": curseforgeAPIPagination{Index: index, PageSize: curseforgeAPIFilesPageSize, ResultCount: len(page), TotalCount: len(cs.files)},
+		})
+	})
+	mux.HandleFunc("GET /v1/mods/238222/files/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for _, f := range cs.files {
+			if strconv.Itoa(f.ID) == r.PathValue("id") {
+				writeJSON(t, w, map[string]any{"data": f})
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	cs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != curseforgeTestKey {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(cs.Close)
+	return cs
+}
+
+func curseforgeTestSettings(srv *httptest.Server) *Settings {
+	return testSettings(srv).WithCurseForgeAPIKey(curseforgeTestKey)
+}
+
+func TestCurseforgeAPIGetModVersions(t *testing.T) {
+	var files []CurseforgeAPIFile
+	// Two pages, with the only beta file on the second one.
+	for id := 200; id > 150; id-- {
+		files = append(files, curseforgeTestFile(id, 1, "1.20.1", "Forge", "Client", "Java 17"))
+	}
+	files = append(files, curseforgeTestFile(150, 2, "1.20.1", "Forge", "Fabric"))
+	cs := newCurseforgeTestServer(t, "jei-versions", files)
+
+	versions, err := GetModVersions(curseforgeTestSettings(cs.Server), testConfig("1.20.1", "forge"), "jei-versions", "curseforge")
+	if err != nil {
+		t.Fatalf("GetModVersions() error = %v", err)
+	}
+	if len(versions) != len(files) {
+		t.Fatalf("GetModVersions() returned %d versions, want %d", len(versions), len(files))
+	}
+	loaderTypes, slugs := cs.requests()
+	if !slices.Equal(loaderTypes, []string{"1", "1"}) {
+		t.Errorf("modLoaderType = %v, want forge (1) on both pages", loaderTypes)
+	}
+	if !slices.Equal(slugs, []string{"jei-versions"}) {
+		t.Errorf("searched slugs = %v, want one lookup", slugs)
+	}
+
+	v := versions[len(versions)-1]
+	if v.ID != "150" || v.ProjectID != "238222" || v.Channel != ChannelBeta || v.FileName != "jei-150.jar" {
+		t.Errorf("last version = %+v", v)
+	}
+	if !slices.Equal(v.GameVersions, []string{"1.20.1"}) || !slices.Equal(v.Loaders, []string{"forge", "fabric"}) {
+		t.Errorf("game versions = %v, loaders = %v", v.GameVersions, v.Loaders)
+	}
+}
+
+func TestCurseforgeAPIGetLatestVersion(t *testing.T) {
+	var files []CurseforgeAPIFile
+	files = append(files, curseforgeTestFile(300, 3, "1.20.1", "NeoForge"))
+	files = append(files, curseforgeTestFile(299, 1, "1.20.1", "Forge"))
+	for id := 298; id > 240; id-- {
+		files = append(files, curseforgeTestFile(id, 1, "1.20.1", "NeoForge"))
+	}
+	cs := newCurseforgeTestServer(t, "jei-latest", files)
+	s := curseforgeTestSettings(cs.Server)
+
+	// NeoForge on 1.20.1 also accepts Forge files, so the loader is not
+	// filtered by the API and newer Forge files must lose to NeoForge ones.
+	v, err := GetLatestVersion(s, testConfig("1.20.1", "neoforge"), "jei-latest", "curseforge", ChannelRelease)
+	if err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+	if v.ID != "298" || v.FallbackLoader != "" {
+		t.Errorf("GetLatestVersion() = %s (fallback %q), want 298", v.ID, v.FallbackLoader)
+	}
+	// The newest release is on the first page, so the second is not read.
+	if loaderTypes, _ := cs.requests(); !slices.Equal(loaderTypes, []string{""}) {
+		t.Errorf("modLoaderType = %q, want one request without a loader filter", loaderTypes)
+	}
+
+	v, err = GetLatestVersion(s, testConfig("1.20.1", "neoforge"), "jei-latest", "curseforge", ChannelAlpha)
+	if err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+	if v.ID != "300" || v.Channel != ChannelAlpha {
+		t.Errorf("GetLatestVersion(alpha) = %s (%s), want 300", v.ID, v.Channel)
+	}
+}
+
+func TestCurseforgeAPIGetVersion(t *testing.T) {
+	noURL := curseforgeTestFile(101, 1, "1.20.1", "Fabric")
+	noURL.DownloadURL = ""
+	cs := newCurseforgeTestServer(t, "jei-version", []CurseforgeAPIFile{curseforgeTestFile(102, 1, "1.20.1", "Fabric"), noURL})
+	s := curseforgeTestSettings(cs.Server)
+	cfg := testConfig("1.20.1", "fabric")
+
+	v, err := GetVersion(s, cfg, "jei-version", "curseforge", "102")
+	if err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+	if v.DownloadURL != "https://edge.forgecdn.net/files/102/jei.jar" {
+		t.Errorf("DownloadURL = %s", v.DownloadURL)
+	}
+
+	// Files of projects that opt out of third-party downloads fall back to
+	// the website download endpoint.
+	v, err = GetVersion(s, cfg, "jei-version", "curseforge", "101")
+	if err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+	if want := cs.URL + "/api/v1/mods/238222/files/101/download"; v.DownloadURL != want {
+		t.Errorf("DownloadURL = %s, want %s", v.DownloadURL, want)
+	}
+
+	// A stored project ID is used instead of resolving the slug.
+	cfg.Mods["renamed"] = config.Mod{Platform: "curseforge", ProjectID: "238222"}
+	if _, err := GetVersion(s, cfg, "renamed", "curseforge", "102"); err != nil {
+		t.Fatalf("GetVersion() by project ID error = %v", err)
+	}
+	if _, slugs := cs.requests(); !slices.Equal(slugs, []string{"jei-version"}) {
+		t.Errorf("searched slugs = %v, want only the first lookup", slugs)
+	}
+}
+
+func TestCurseforgeAPIKey(t *testing.T) {
+	cs := newCurseforgeTestServer(t, "jei-key", nil)
+	s := testSettings(cs.Server).WithCurseForgeAPIKey("wrong-key")
+
+	_, err := curseforgeAPIModID(s, "jei-key")
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("curseforgeAPIModID() with a rejected key error = %v, want BlockedError", err)
+	}
+}
+
+func TestCurseforgeAPIIdentifyFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/fingerprints/" + curseforgeMinecraftGameID:
+			var body struct {
+				Fingerprints []uint32 `json:"fingerprints"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding body: %v", err)
+			}
+			if !slices.Equal(body.Fingerprints, []uint32{7, 8}) {
+				t.Errorf("fingerprints = %v", body.Fingerprints)
+			}
+			writeJSON(t, w, map[string]any{"data": map[string]any{"exactMatches": []map[string]any{
+				{"id": 238222, "file": curseforgeTestFile(8, 1, "1.20.1", "Forge")},
+			}}})
+		case "POST /v1/mods":
+			writeJSON(t, w, map[string]any{"data": []map[string]any{
+				{"id": 238222, "slug": "jei", "name": "Just Enough Items", "links": map[string]string{"websiteUrl": "https://www.curseforge.com/minecraft/mc-mods/jei"}},
+			}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	identified, err := identifyFilesCurseforgeAPI(curseforgeTestSettings(srv), []FileHashes{{Murmur2: 7}, {Murmur2: 8}})
+	if err != nil {
+		t.Fatalf("identifyFilesCurseforgeAPI() error = %v", err)
+	}
+	if len(identified) != 1 {
+		t.Fatalf("identified %d files, want 1", len(identified))
+	}
+	f := identified[1]
+	if f.ModID != "jei" || f.Platform != "curseforge" || f.Version.ID != "8" || f.Source != "https://www.curseforge.com/minecraft/mc-mods/jei" {
+		t.Errorf("identified file = %+v", f)
+	}
+}
+
+func TestCurseforgeAPISearchCategories(t *testing.T) {
+	var categoryIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		categoryIDs = append(categoryIDs, r.URL.Query().Get("categoryIds"))
+		writeJSON(t, w, map[string]any{"data": []any{}})
+	}))
+	t.Cleanup(srv.Close)
+	s := curseforgeTestSettings(srv)
+	cfg := testConfig("1.20.1", "fabric")
+
+	// Modrinth names are mapped, IDs kept and names without a counterpart
+	// left out.
+	opts := SearchOptions{Page: 1, PageSize: 20, Categories: []string{"Optimization", "economy", "407", "worldgen"}}
+	if _, err := SearchMods(s, cfg, "sodium", "curseforge", opts); err != nil {
+		t.Fatalf("SearchMods() error = %v", err)
+	}
+	if want := []string{"[6814,407,406]"}; !slices.Equal(categoryIDs, want) {
+		t.Errorf("categoryIds = %v, want %v", categoryIDs, want)
+	}
+
+	opts.Categories = []string{"not-a-category"}
+	if _, err := SearchMods(s, cfg, "sodium", "curseforge", opts); err == nil {
+		t.Error("SearchMods() accepted an unknown category")
+	}
+}
@@ -0,0 +1,98 @@
+package sources
+
+import (
+	"fmt"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+// Dependency is a mod that has to be added together with another mod,
+// resolved to a version that is compatible with the project.
+type Dependency struct {
+	ModID      string
+	ProjectID  string
+	Name       string
+	Version    string
+	VersionID  string
+	FileName   string
+	URL        string
+	Source     string
+	RequiredBy string
+	// Unresolved is why a required dependency could not be resolved to a
+	// version, such as when it has none for the project. Unresolved
+	// dependencies are reported but not added.
+	Unresolved string
+}
+
+// DependencyProvider is implemented by providers that know the required
+// dependencies of a mod version.
+type DependencyProvider interface {
+	GetDependencies(s *Settings, cfg *config.Config, modID, versionID string) ([]Dependency, error)
+}
+
+// ResolveDependencies returns every required dependency of the given mod
+// version, walking the dependency tree transitively, and separately the
+// required dependencies that could not be resolved. Mods that are already
+// in the project, by key or by project ID, are skipped.
+func ResolveDependencies(s *Settings, cfg *config.Config, modID, platform, versionID string) (resolved, unresolved []Dependency, err error) {
+	logger.Log.Printf("Resolving dependencies for mod: %s on platform: %s", modID, platform)
+	p, err := GetProvider(platform)
+	if err != nil {
+		return nil, nil, err
+	}
+	dp, ok := p.(DependencyProvider)
+	if !ok {
+		logger.Log.Printf("Platform %s does not support dependency resolution", platform)
+		return nil, nil, nil
+	}
+
+	seen := map[string]bool{modID: true}
+	for id, mod := range cfg.Mods {
+		seen[id] = true
+		if mod.ProjectID != "" {
+			seen[mod.ProjectID] = true
+		}
+	}
+
+	queue := []Dependency{{ModID: modID, VersionID: versionID}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		deps, err := dp.GetDependencies(s, cfg, current.ModID, current.VersionID)
+		if err != nil {
+			logger.Log.Printf("Error getting dependencies for mod %s: %v", current.ModID, err)
+			return nil, nil, fmt.Errorf("%s: %w", current.ModID, err)
+		}
+		for _, dep := range deps {
+			if seen[dep.ModID] || (dep.ProjectID != "" && seen[dep.ProjectID]) {
+				logger.Log.Printf("Dependency %s already present, skipping", dep.ModID)
+				continue
+			}
+			seen[dep.ModID] = true
+			if dep.ProjectID != "" {
+				seen[dep.ProjectID] = true
+			}
+			dep.RequiredBy = current.ModID
+			if dep.Unresolved != "" {
+				logger.Log.Printf("Dependency %s of %s is unresolved: %s", dep.ModID, current.ModID, dep.Unresolved)
+				unresolved = append(unresolved, dep)
+				continue
+			}
+			resolved = append(resolved, dep)
+			queue = append(queue, dep)
+		}
+	}
+
+	logger.Log.Printf("Resolved %d dependencies for mod: %s, %d unresolved", len(resolved), modID, len(unresolved))
+	return resolved, unresolved, nil
+}
+
+// DownloadName is the filename hint passed to fs.Download.
+func (d Dependency) DownloadName() string {
+	if d.FileName != "" {
+		return d.FileName
+	}
+	return d.Version
+}
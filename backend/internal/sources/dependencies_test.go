@@ -0,0 +1,133 @@
+package sources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestModrinthResolveDependencies(t *testing.T) {
+	withDeps := func(v map[string]any, deps ...map[string]any) map[string]any {
+		v["dependencies"] = deps
+		return v
+	}
+	versions := map[string]map[string]any{
+		"main": withDeps(modrinthTestVersion("main", "1.0", ChannelRelease, "1.20.1", "fabric"),
+			map[string]any{"project_id": "LIB", "dependency_type": "required"},
+			map[string]any{"project_id": "OLD", "dependency_type": "required"},
+			map[string]any{"file_name": "external.jar", "dependency_type": "required"},
+			map[string]any{"project_id": "OPT", "dependency_type": "optional"},
+		),
+		"lib1": withDeps(modrinthTestVersion("lib1", "2.0", ChannelRelease, "1.20.1", "fabric"),
+			map[string]any{"project_id": "CORE", "dependency_type": "required"},
+		),
+		"core1": modrinthTestVersion("core1", "3.0", ChannelRelease, "1.20.1", "fabric"),
+		"old1":  modrinthTestVersion("old1", "0.1", ChannelRelease, "1.19.2", "fabric"),
+	}
+	projects := map[string]struct {
+		slug     string
+		versions []string
+	}{
+		"LIB":  {slug: "lib", versions: []string{"lib1"}},
+		"CORE": {slug: "core", versions: []string{"core1"}},
+		"OLD":  {slug: "oldlib", versions: []string{"old1"}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /version/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, versions[r.PathValue("id")])
+	})
+	mux.HandleFunc("GET /project/{id}", func(w http.ResponseWriter, r *http.Request) {
+		p := projects[r.PathValue("id")]
+		writeJSON(t, w, map[string]any{"id": r.PathValue("id"), "slug": p.slug, "title": p.slug})
+	})
+	mux.HandleFunc("GET /project/{id}/version", func(w http.ResponseWriter, r *http.Request) {
+		var list []map[string]any
+		for _, id := range projects[r.PathValue("id")].versions {
+			list = append(list, versions[id])
+		}
+		writeJSON(t, w, list)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	resolved, unresolved, err := ResolveDependencies(testSettings(srv), testConfig("1.20.1", "fabric"), "main", "modrinth", "main")
+	if err != nil {
+		t.Fatalf("ResolveDependencies() error = %v", err)
+	}
+
+	// Dependencies of dependencies are resolved too.
+	if len(resolved) != 2 || resolved[0].ModID != "lib" || resolved[1].ModID != "core" || resolved[1].RequiredBy != "lib" {
+		t.Errorf("resolved = %+v, want lib and core", resolved)
+	}
+	if resolved[0].VersionID != "lib1" || resolved[0].Unresolved != "" {
+		t.Errorf("lib = %+v", resolved[0])
+	}
+
+	// A dependency without a version for the project, and one that is not on
+	// Modrinth, are reported instead of failing the whole resolution.
+	if len(unresolved) != 2 {
+		t.Fatalf("unresolved = %+v, want oldlib and external.jar", unresolved)
+	}
+	for i, want := range []string{"oldlib", "external.jar"} {
+		if dep := unresolved[i]; dep.ModID != want || dep.Unresolved == "" || dep.RequiredBy != "main" {
+			t.Errorf("unresolved[%d] = %+v, want %s required by main", i, dep, want)
+		}
+	}
+}
+
+func TestCurseforgeAPIResolveDependencies(t *testing.T) {
+	mods := map[string]CurseforgeAPIMod{
+		"501": {ID: 501, Slug: "cf-lib", Name: "Library"},
+		"502": {ID: 502, Slug: "cf-forge-only", Name: "Forge Only"},
+	}
+	files := map[string][]CurseforgeAPIFile{
+		"501": {curseforgeTestFile(5010, 1, "1.20.1", "Fabric")},
+		"502": {curseforgeTestFile(5020, 1, "1.20.1", "Forge")},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/mods/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]any{"data": mods[r.PathValue("id")]})
+	})
+	mux.HandleFunc("GET /v1/mods/{id}/files", func(w http.ResponseWriter, r *http.Request) {
+		list := files[r.PathValue("id")]
+		writeJSON(t, w, map[string]any{
+			"data":       list,
+			"pagination": curseforgeAPIPagination{PageSize: curseforgeAPIFilesPageSize, ResultCount: len(list), TotalCount: len(list)},
+		})
+	})
+	mux.HandleFunc("GET /v1/mods/{id}/files/{file}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("file"))
+		file := map[string]any{"id": id}
+		if r.PathValue("id") == "500" {
+			file["dependencies"] = []map[string]int{
+				{"modId": 501, "relationType": curseforgeRequiredDependency},
+				{"modId": 502, "relationType": curseforgeRequiredDependency},
+				{"modId": 503, "relationType": 2},
+			}
+		}
+		writeJSON(t, w, map[string]any{"data": file})
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != curseforgeTestKey {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	resolved, unresolved, err := ResolveDependencies(curseforgeTestSettings(srv), testConfig("1.20.1", "fabric"), "500", "curseforge", "5000")
+	if err != nil {
+		t.Fatalf("ResolveDependencies() error = %v", err)
+	}
+	if len(resolved) != 1 || resolved[0].ModID != "cf-lib" || resolved[0].VersionID != "5010" {
+		t.Errorf("resolved = %+v, want cf-lib", resolved)
+	}
+	// A dependency with files only for another loader is reported.
+	if len(unresolved) != 1 || unresolved[0].ModID != "cf-forge-only" || unresolved[0].Unresolved == "" || unresolved[0].RequiredBy != "500" {
+		t.Errorf("unresolved = %+v, want cf-forge-only", unresolved)
+	}
+}
@@ -0,0 +1,70 @@
+package sources
+
+import (
+	"fmt"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+// ModDetails is the full description of a mod shown before it is added.
+// Body is Markdown on Modrinth and HTML on CurseForge, BodyFormat says which.
+type ModDetails struct {
+	ID          string
+	ProjectID   string
+	Platform    string
+	Name        string
+	Description string
+	IconURL     string
+	Authors     []string
+	License     string
+	LicenseURL  string
+	Categories  []string
+	URL         string
+	SourceURL   string
+	IssuesURL   string
+	WikiURL     string
+	Downloads   int64
+	Followers   int64
+	Updated     string
+	Gallery     []GalleryImage
+	Body        string
+	BodyFormat  string
+}
+
+// Formats of ModDetails.Body.
+const (
+	BodyMarkdown = "markdown"
+	BodyHTML     = "html"
+)
+
+type GalleryImage struct {
+	URL         string
+	Title       string
+	Description string
+}
+
+// DetailsProvider is implemented by providers that can describe a mod in
+// full.
+type DetailsProvider interface {
+	GetModDetails(s *Settings, cfg *config.Config, modID string) (*ModDetails, error)
+}
+
+func GetModDetails(s *Settings, cfg *config.Config, modID, platform string) (*ModDetails, error) {
+	logger.Log.Printf("Getting details for mod: %s on platform: %s", modID, platform)
+	p, err := GetProvider(platform)
+	if err != nil {
+		return nil, err
+	}
+	dp, ok := p.(DetailsProvider)
+	if !ok {
+		logger.Log.Printf("Platform %s does not provide mod details", platform)
+		return nil, fmt.Errorf("mod details are not available on %s", platform)
+	}
+	details, err := dp.GetModDetails(s, cfg, modID)
+	if err != nil {
+		return nil, err
+	}
+	details.Platform = platform
+	return details, nil
+}
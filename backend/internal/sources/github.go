@@ -0,0 +1,279 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+func init() {
+	Register(githubProvider{})
+}
+
+// githubProvider lists the jar assets of a repository's releases as mod
+// versions. Mods are identified by their "owner/repo" name.
+type githubProvider struct{}
+
+func (githubProvider) Name() string { return "github" }
+
+func (githubProvider) SearchMods(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	return searchModsGitHub(s, cfg, query, opts)
+}
+
+func (githubProvider) GetModVersions(s *Settings, cfg *config.Config, modID string) ([]ModVersion, error) {
+	return getModVersionsGitHub(s, cfg, projectRef(cfg, modID))
+}
+
+func (githubProvider) GetVersion(s *Settings, cfg *config.Config, modID, versionID string) (ModVersion, error) {
+	return getVersionGitHub(s, cfg, projectRef(cfg, modID), versionID)
+}
+
+func (githubProvider) GetLatestVersion(s *Settings, cfg *config.Config, modID, channel string) (ModVersion, error) {
+	logger.Log.Printf("Getting latest version for GitHub mod: %s", modID)
+	versions, err := getModVersionsGitHub(s, cfg, projectRef(cfg, modID))
+	if err != nil {
+		return ModVersion{}, err
+	}
+	return latestVersion(cfg, versions, channel)
+}
+
+type GitHubRepository struct {
+	FullName        string `json:"full_name"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	HTMLURL         string `json:"html_url"`
+	StargazersCount int    `json:"stargazers_count"`
+}
+
+type GitHubAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	DownloadCount      int    `json:"download_count"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+type GitHubRelease struct {
+	ID          int64         `json:"id"`
+	TagName     string        `json:"tag_name"`
+	Name        string        `json:"name"`
+	Body        string        `json:"body"`
+	Draft       bool          `json:"draft"`
+	Prerelease  bool          `json:"prerelease"`
+	PublishedAt string        `json:"published_at"`
+	Assets      []GitHubAsset `json:"assets"`
+}
+
+func fetchGitHubJSON(s *Settings, endpoint string, out any) error {
+	_, err := fetchGitHubPage(s, s.GitHubAPIURL+endpoint, out)
+	return err
+}
+
+// fetchGitHubPage decodes one page of a GitHub API listing and returns the
+// URL of the next page, empty on the last page.
+func fetchGitHubPage(s *Settings, reqURL string, out any) (string, error) {
+	req, err := s.newRequest("GET", reqURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if s.GitHubToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.GitHubToken)
+	}
+
+	logger.Log.Printf("Making HTTP request to GitHub API: %s", reqURL)
+	resp, err := s.do(req)
+	if err != nil {
+		logger.Log.Printf("Error making request: %v", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.Log.Printf("GitHub API returned status %d", resp.StatusCode)
+		return "", statusError("github api", resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		logger.Log.Printf("Error decoding JSON response: %v", err)
+		return "", err
+	}
+	return githubNextPage(resp.Header.Get("Link")), nil
+}
+
+// githubNextPage returns the rel="next" URL of a Link header.
+func githubNextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+	return ""
+}
+
+// githubMaxReleasePages bounds the release pages read for a repository, at
+// 100 releases per page.
+const githubMaxReleasePages = 10
+
+// githubRepo validates an "owner/repo" mod ID.
+func githubRepo(id string) (string, error) {
+	owner, repo, ok := strings.Cut(strings.Trim(id, "/"), "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", fmt.Errorf("github mods must be given as owner/repo, got %q", id)
+	}
+	return url.PathEscape(owner) + "/" + url.PathEscape(repo), nil
+}
+
+// searchModsGitHub returns the repository itself when the query is an
+// "owner/repo" name, and searches repositories otherwise.
+func searchModsGitHub(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	logger.Log.Printf("Searching GitHub for query: %s, page: %d", query, opts.Page)
+	var repos []GitHubRepository
+	total := 0
+	if repo, err := githubRepo(query); err == nil {
+		var r GitHubRepository
+		if err := fetchGitHubJSON(s, "/repos/"+repo, &r); err != nil {
+			return nil, err
+		}
+		repos = append(repos, r)
+		total = 1
+	} else {
+		params := url.Values{
+			"q":        {query + " minecraft in:name,description,topics"},
+			"per_page": {strconv.Itoa(opts.PageSize)},
+			"page":     {strconv.Itoa(opts.Page)},
+		}
+		switch opts.Sort {
+		case SortFollows, SortDownloads:
+			params.Set("sort", "stars")
+		case SortUpdated, SortNewest:
+			params.Set("sort", "updated")
+		}
+		var data struct {
+			TotalCount int                `json:"total_count"`
+			Items      []GitHubRepository `json:"items"`
+		}
+		if err := fetchGitHubJSON(s, "/search/repositories?"+params.Encode(), &data); err != nil {
+			return nil, err
+		}
+		repos = data.Items
+		total = data.TotalCount
+	}
+
+	mods := make([]ModSearch, 0, len(repos))
+	for _, r := range repos {
+		mods = append(mods, ModSearch{
+			ID:          r.FullName,
+			Name:        r.Name,
+			Description: r.Description,
+			Downloads:   strconv.Itoa(r.StargazersCount),
+			URL:         r.HTMLURL,
+		})
+	}
+
+	err := fillVersions(mods, func(id string) ([]ModVersion, error) {
+		return getModVersionsGitHub(s, cfg, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Printf("Found %d mods on GitHub, %d total", len(mods), total)
+	return &SearchResult{Hits: mods, TotalHits: total, Page: opts.Page, PageSize: opts.PageSize}, nil
+}
+
+func getModVersionsGitHub(s *Settings, cfg *config.Config, id string) ([]ModVersion, error) {
+	repo, err := githubRepo(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []GitHubRelease
+	next := s.GitHubAPIURL + "/repos/" + repo + "/releases?per_page=100"
+	for page := 0; next != "" && page < githubMaxReleasePages; page++ {
+		var pageReleases []GitHubRelease
+		next, err = fetchGitHubPage(s, next, &pageReleases)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, pageReleases...)
+	}
+
+	var versions []ModVersion
+	for _, r := range releases {
+		if r.Draft {
+			continue
+		}
+		channel := ChannelRelease
+		if r.Prerelease {
+			channel = ChannelBeta
+		}
+		for _, a := range r.Assets {
+			if !githubAssetCompatible(cfg, r, a) {
+				continue
+			}
+			versions = append(versions, ModVersion{
+				ID:          strconv.FormatInt(a.ID, 10),
+				ProjectID:   strings.Trim(id, "/"),
+				Version:     r.TagName,
+				Channel:     channel,
+				Published:   r.PublishedAt,
+				FileName:    a.Name,
+				FileSize:    a.Size,
+				Loaders:     namedLoaders(a.Name),
+				Changelog:   r.Body,
+				DownloadURL: a.BrowserDownloadURL,
+			})
+		}
+	}
+	logger.Log.Printf("Found %d compatible assets for GitHub mod: %s", len(versions), id)
+	return versions, nil
+}
+
+func getVersionGitHub(s *Settings, cfg *config.Config, id, assetID string) (ModVersion, error) {
+	logger.Log.Printf("Getting asset %s for GitHub mod: %s", assetID, id)
+	versions, err := getModVersionsGitHub(s, cfg, id)
+	if err != nil {
+		return ModVersion{}, err
+	}
+	for _, v := range versions {
+		if v.ID == assetID {
+			return v, nil
+		}
+	}
+	logger.Log.Printf("Could not find asset: %s", assetID)
+	return ModVersion{}, fmt.Errorf("could not find asset: %s", assetID)
+}
+
+var githubIgnoredSuffixes = []string{"-sources.jar", "-javadoc.jar", "-dev.jar", "-api.jar", "-slim.jar"}
+
+// githubAssetCompatible reports whether a release asset is a mod jar for the
+// project. The Minecraft version has to be named by the asset, the release
+// tag or title, and an asset that names loaders has to name the project
+// loader. Release notes are not checked, changelogs routinely mention other
+// Minecraft versions.
+func githubAssetCompatible(cfg *config.Config, r GitHubRelease, a GitHubAsset) bool {
+	name := strings.ToLower(a.Name)
+	if !strings.HasSuffix(name, ".jar") {
+		return false
+	}
+	for _, suffix := range githubIgnoredSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+
+	if loaders := namedLoaders(a.Name); len(loaders) > 0 && !acceptsLoaders(cfg, loaders) {
+		return false
+	}
+
+	mc := minecraftVersionPattern(cfg.Minecraft)
+	return mc.MatchString(a.Name) || mc.MatchString(r.TagName) || mc.MatchString(r.Name)
+}
@@ -0,0 +1,144 @@
+package sources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func githubTestRelease(id int64, tag, name string, prerelease bool, assets ...string) GitHubRelease {
+	r := GitHubRelease{ID: id, TagName: tag, Name: name, Prerelease: prerelease}
+	for i, asset := range assets {
+		assetID := id*10 + int64(i)
+		r.Assets = append(r.Assets, GitHubAsset{
+			ID:                 assetID,
+			Name:               asset,
+			BrowserDownloadURL: fmt.Sprintf("https://github.com/owner/mod/releases/download/%s/%s", tag, asset),
+		})
+	}
+	return r
+}
+
+// newGitHubTestServer serves the releases of owner/mod over two pages that
+// are linked by the Link header.
+func newGitHubTestServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	pages := [][]GitHubRelease{
+		{
+			githubTestRelease(3, "v3.0", "3.0 draft", false, "mod-fabric-1.20.1-3.0.jar"),
+			githubTestRelease(2, "v2.0-beta", "2.0 beta", true,
+				"mod-fabric-1.20.1-2.0.jar",
+				"mod-forge-1.20.1-2.0.jar",
+				"mod-fabric-1.20.1-2.0-sources.jar",
+				"mod-2.0.zip",
+			),
+		},
+		{
+			// The Minecraft version is only named by the release title.
+			githubTestRelease(1, "v1.0", "1.0 for Minecraft 1.20.1", false, "mod-1.0.jar"),
+			githubTestRelease(0, "v0.9", "0.9 for Minecraft 1.20", false, "mod-0.9.jar"),
+		},
+	}
+	pages[0][0].Draft = true
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/mod/releases" {
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); token != "" && got != "Bearer "+token {
+			t.Errorf("Authorization = %q, want the token", got)
+		}
+		if r.URL.Query().Get("page") == "2" {
+			writeJSON(t, w, pages[1])
+			return
+		}
+		next := srv.URL + "/repos/owner/mod/releases?per_page=100&page=2"
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", <%s>; rel="last"`, next, next))
+		writeJSON(t, w, pages[0])
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitHubGetModVersions(t *testing.T) {
+	srv := newGitHubTestServer(t, "token")
+	s := testSettings(srv)
+	s.GitHubToken = "token"
+
+	versions, err := GetModVersions(s, testConfig("1.20.1", "fabric"), "owner/mod", "github")
+	if err != nil {
+		t.Fatalf("GetModVersions() error = %v", err)
+	}
+	var names []string
+	for _, v := range versions {
+		names = append(names, v.FileName)
+	}
+	// Drafts, other loaders, source jars, other files and releases for
+	// other Minecraft versions are left out.
+	if want := []string{"mod-fabric-1.20.1-2.0.jar", "mod-1.0.jar"}; !slices.Equal(names, want) {
+		t.Fatalf("assets = %v, want %v", names, want)
+	}
+	if v := versions[0]; v.ID != "20" || v.Channel != ChannelBeta || !slices.Equal(v.Loaders, []string{"fabric"}) {
+		t.Errorf("first version = %+v", v)
+	}
+	if v := versions[1]; v.ID != "10" || v.Version != "v1.0" || v.Channel != ChannelRelease || v.ProjectID != "owner/mod" {
+		t.Errorf("second version = %+v", v)
+	}
+}
+
+func TestGitHubGetLatestVersion(t *testing.T) {
+	srv := newGitHubTestServer(t, "")
+	s := testSettings(srv)
+	cfg := testConfig("1.20.1", "fabric")
+
+	v, err := GetLatestVersion(s, cfg, "owner/mod", "github", ChannelRelease)
+	if err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+	if v.FileName != "mod-1.0.jar" {
+		t.Errorf("GetLatestVersion(release) = %s, want the release from the second page", v.FileName)
+	}
+
+	v, err = GetVersion(s, cfg, "owner/mod", "github", "20")
+	if err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+	if v.DownloadURL != "https://github.com/owner/mod/releases/download/v2.0-beta/mod-fabric-1.20.1-2.0.jar" {
+		t.Errorf("DownloadURL = %s", v.DownloadURL)
+	}
+}
+
+func TestGitHubNextPage(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{link: "", want: ""},
+		{link: `<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, want: "https://api.github.com/x?page=2"},
+		{link: `<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=3>; rel="next"`, want: "https://api.github.com/x?page=3"},
+		{link: `<https://api.github.com/x?page=1>; rel="first", <https://api.github.com/x?page=4>; rel="prev"`, want: ""},
+	}
+	for _, tt := range tests {
+		if got := githubNextPage(tt.link); got != tt.want {
+			t.Errorf("githubNextPage(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubRepo(t *testing.T) {
+	for _, id := range []string{"owner/mod", "/owner/mod/"} {
+		if repo, err := githubRepo(id); err != nil || repo != "owner/mod" {
+			t.Errorf("githubRepo(%q) = %q, %v", id, repo, err)
+		}
+	}
+	for _, id := range []string{"mod", "owner/", "owner/mod/extra"} {
+		if _, err := githubRepo(id); err == nil {
+			t.Errorf("githubRepo(%q) accepted an invalid repository", id)
+		}
+	}
+}
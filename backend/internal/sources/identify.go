@@ -0,0 +1,109 @@
+package sources
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+// FileHashes are the digests of a local jar that platforms use to identify
+// it. Murmur2 is the CurseForge fingerprint of the file.
+type FileHashes struct {
+	SHA1    string
+	SHA512  string
+	Murmur2 uint32
+}
+
+// IdentifiedFile is a local jar matched to a version of a mod.
+type IdentifiedFile struct {
+	ModID    string
+	Name     string
+	Platform string
+	Source   string
+	Side     string
+	Version  ModVersion
+}
+
+// FileIdentifier is implemented by providers that can match local files to
+// mod versions by their hashes. The returned map is keyed by the index of
+// the file in files, files that were not recognised are left out.
+type FileIdentifier interface {
+	IdentifyFiles(s *Settings, cfg *config.Config, files []FileHashes) (map[int]IdentifiedFile, error)
+}
+
+// identifyFirst are the providers asked before the others to identify
+// files. Modrinth records which side a mod runs on, the CurseForge API does
+// not, so jars published on both are attributed to Modrinth.
+var identifyFirst = []string{"modrinth"}
+
+// identifyOrder returns the registered providers in the order they are
+// asked to identify files.
+func identifyOrder() []string {
+	names := Providers()
+	slices.SortStableFunc(names, func(a, b string) int {
+		return cmp.Compare(identifyRank(a), identifyRank(b))
+	})
+	return names
+}
+
+func identifyRank(name string) int {
+	if i := slices.Index(identifyFirst, name); i >= 0 {
+		return i
+	}
+	return len(identifyFirst)
+}
+
+// IdentifyFiles asks every provider that supports it to identify the files,
+// in identifyOrder, and returns the matches keyed by file index. Provider
+// errors are logged and the remaining providers are still asked.
+func IdentifyFiles(s *Settings, cfg *config.Config, files []FileHashes) map[int]IdentifiedFile {
+	logger.Log.Printf("Identifying %d files", len(files))
+	identified := map[int]IdentifiedFile{}
+	for _, name := range identifyOrder() {
+		p, err := GetProvider(name)
+		if err != nil {
+			continue
+		}
+		fi, ok := p.(FileIdentifier)
+		if !ok {
+			continue
+		}
+
+		var pending []FileHashes
+		var indexes []int
+		for i, f := range files {
+			if _, done := identified[i]; !done {
+				pending = append(pending, f)
+				indexes = append(indexes, i)
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+
+		matches, err := fi.IdentifyFiles(s, cfg, pending)
+		if err != nil {
+			logger.Log.Printf("Error identifying files on %s: %v", name, err)
+			continue
+		}
+		for i, match := range matches {
+			identified[indexes[i]] = match
+		}
+		logger.Log.Printf("Identified %d files on %s", len(matches), name)
+	}
+	return identified
+}
+
+// sideFromSupport converts Modrinth style client and server support values
+// to the side a mod is installed on.
+func sideFromSupport(clientSide, serverSide string) string {
+	switch {
+	case clientSide == "unsupported":
+		return "server"
+	case serverSide == "unsupported":
+		return "client"
+	}
+	return "both"
+}
@@ -0,0 +1,81 @@
+package sources
+
+import (
+	"slices"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+)
+
+// loaderFallback lets a project loader run mods built for another loader,
+// optionally only on some Minecraft versions.
+type loaderFallback struct {
+	loader    string
+	accepts   string
+	minecraft []string
+}
+
+// loaderFallbacks is the loader compatibility table, in order of preference
+// after the project loader itself. Quilt loads Fabric mods, and NeoForge on
+// 1.20.1 still loads Forge mods since it had not diverged from Forge yet.
+var loaderFallbacks = []loaderFallback{
+	{loader: "quilt", accepts: "fabric"},
+	{loader: "neoforge", accepts: "forge", minecraft: []string{"1.20.1"}},
+}
+
+// AcceptedLoaders returns the loaders whose mods run on the project loader,
+// most preferred first. The project loader always comes first.
+func AcceptedLoaders(loader, minecraft string) []string {
+	accepted := []string{loader}
+	for _, f := range loaderFallbacks {
+		if f.loader == loader && (len(f.minecraft) == 0 || slices.Contains(f.minecraft, minecraft)) {
+			accepted = append(accepted, f.accepts)
+		}
+	}
+	return accepted
+}
+
+func acceptedLoaders(cfg *config.Config) []string {
+	return AcceptedLoaders(cfg.Loader, cfg.Minecraft)
+}
+
+// acceptsLoaders reports whether any of the loaders runs on the project.
+func acceptsLoaders(cfg *config.Config, loaders []string) bool {
+	return slices.ContainsFunc(acceptedLoaders(cfg), func(l string) bool {
+		return slices.Contains(loaders, l)
+	})
+}
+
+// fallbackLoader returns the loader a version is used through when it does
+// not support the project loader itself. Versions that do not name their
+// loaders are taken to support the project loader.
+func fallbackLoader(cfg *config.Config, v ModVersion) string {
+	if len(v.Loaders) == 0 || slices.Contains(v.Loaders, cfg.Loader) {
+		return ""
+	}
+	for _, l := range acceptedLoaders(cfg)[1:] {
+		if slices.Contains(v.Loaders, l) {
+			return l
+		}
+	}
+	return ""
+}
+
+// withFallbackLoaders records on each version whether it was matched through
+// a fallback loader.
+func withFallbackLoaders(cfg *config.Config, versions []ModVersion) []ModVersion {
+	for i := range versions {
+		versions[i].FallbackLoader = fallbackLoader(cfg, versions[i])
+	}
+	return versions
+}
+
+// compatibleLoaders keeps the versions that run on the project loader.
+func compatibleLoaders(cfg *config.Config, versions []ModVersion) []ModVersion {
+	var compatible []ModVersion
+	for _, v := range versions {
+		if len(v.Loaders) == 0 || acceptsLoaders(cfg, v.Loaders) {
+			compatible = append(compatible, v)
+		}
+	}
+	return compatible
+}
@@ -0,0 +1,229 @@
+package sources
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+func init() {
+	Register(mavenProvider{})
+}
+
+// mavenProvider resolves mods published to Maven repositories. Mods are
+// identified by their "group:artifact" coordinates and the version ID of a
+// Maven version is the version itself.
+type mavenProvider struct{}
+
+func (mavenProvider) Name() string { return "maven" }
+
+func (mavenProvider) SearchMods(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	return searchModsMaven(s, cfg, query, opts)
+}
+
+func (mavenProvider) GetModVersions(s *Settings, cfg *config.Config, modID string) ([]ModVersion, error) {
+	return getModVersionsMaven(s, cfg, projectRef(cfg, modID))
+}
+
+func (mavenProvider) GetVersion(s *Settings, cfg *config.Config, modID, versionID string) (ModVersion, error) {
+	return getVersionMaven(s, cfg, projectRef(cfg, modID), versionID)
+}
+
+func (mavenProvider) GetLatestVersion(s *Settings, cfg *config.Config, modID, channel string) (ModVersion, error) {
+	logger.Log.Printf("Getting latest version for Maven mod: %s", modID)
+	versions, err := getModVersionsMaven(s, cfg, projectRef(cfg, modID))
+	if err != nil {
+		return ModVersion{}, err
+	}
+	return latestVersion(cfg, versions, channel)
+}
+
+type MavenMetadata struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Versioning struct {
+		Latest      string   `xml:"latest"`
+		Release     string   `xml:"release"`
+		Versions    []string `xml:"versions>version"`
+		LastUpdated string   `xml:"lastUpdated"`
+	} `xml:"versioning"`
+}
+
+type mavenCoordinates struct {
+	group, artifact, version string
+}
+
+func (c mavenCoordinates) id() string {
+	return c.group + ":" + c.artifact
+}
+
+func (c mavenCoordinates) path() string {
+	return strings.ReplaceAll(c.group, ".", "/") + "/" + c.artifact
+}
+
+// parseMavenCoordinates parses "group:artifact" or "group:artifact:version".
+func parseMavenCoordinates(id string) (mavenCoordinates, error) {
+	parts := strings.Split(strings.TrimSpace(id), ":")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return mavenCoordinates{}, fmt.Errorf("maven mods must be given as group:artifact or group:artifact:version, got %q", id)
+	}
+	c := mavenCoordinates{group: parts[0], artifact: parts[1]}
+	if len(parts) == 3 {
+		c.version = parts[2]
+	}
+	return c, nil
+}
+
+// fetchMavenMetadata reads maven-metadata.xml from the first configured
+// repository that has the artifact and returns it with that repository.
+func fetchMavenMetadata(s *Settings, c mavenCoordinates) (*MavenMetadata, string, error) {
+	for _, repo := range s.MavenRepos {
+		metadataURL := fmt.Sprintf("%s/%s/maven-metadata.xml", repo, c.path())
+		req, err := s.newRequest("GET", metadataURL, nil)
+		if err != nil {
+			return nil, "", err
+		}
+
+		logger.Log.Printf("Making HTTP request to Maven repository: %s", metadataURL)
+		resp, err := s.do(req)
+		if err != nil {
+			logger.Log.Printf("Error making request: %v", err)
+			return nil, "", err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			logger.Log.Printf("Artifact %s not found in %s", c.id(), repo)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			logger.Log.Printf("Maven repository returned status %d", resp.StatusCode)
+			return nil, "", statusError("maven repository "+repo, resp)
+		}
+
+		var metadata MavenMetadata
+		err = xml.NewDecoder(resp.Body).Decode(&metadata)
+		resp.Body.Close()
+		if err != nil {
+			logger.Log.Printf("Error decoding maven metadata: %v", err)
+			return nil, "", err
+		}
+		return &metadata, repo, nil
+	}
+
+	logger.Log.Printf("Could not find artifact %s in any repository", c.id())
+	return nil, "", fmt.Errorf("could not find maven artifact %s", c.id())
+}
+
+// searchModsMaven looks up the artifact named by the query. Maven
+// repositories cannot be searched, so the query has to be coordinates.
+func searchModsMaven(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	logger.Log.Printf("Looking up Maven artifact: %s", query)
+	c, err := parseMavenCoordinates(query)
+	if err != nil {
+		return nil, err
+	}
+	metadata, repo, err := fetchMavenMetadata(s, c)
+	if err != nil {
+		return nil, err
+	}
+	versions := mavenVersions(cfg, c, metadata, repo)
+	if c.version != "" {
+		versions = slices.DeleteFunc(versions, func(v ModVersion) bool { return v.Version != c.version })
+	}
+
+	mod := ModSearch{
+		ID:       c.id(),
+		Name:     c.artifact,
+		URL:      repo + "/" + c.path(),
+		Versions: versions,
+	}
+	return &SearchResult{Hits: []ModSearch{mod}, TotalHits: 1, Page: 1, PageSize: opts.PageSize}, nil
+}
+
+func getModVersionsMaven(s *Settings, cfg *config.Config, id string) ([]ModVersion, error) {
+	c, err := parseMavenCoordinates(id)
+	if err != nil {
+		return nil, err
+	}
+	metadata, repo, err := fetchMavenMetadata(s, c)
+	if err != nil {
+		return nil, err
+	}
+	versions := mavenVersions(cfg, c, metadata, repo)
+	logger.Log.Printf("Found %d compatible versions for Maven mod: %s", len(versions), id)
+	return versions, nil
+}
+
+func mavenVersions(cfg *config.Config, c mavenCoordinates, metadata *MavenMetadata, repo string) []ModVersion {
+	var versions []ModVersion
+	// maven-metadata.xml lists versions oldest first.
+	for _, version := range slices.Backward(metadata.Versioning.Versions) {
+		if !mavenVersionCompatible(cfg, c.artifact, version) {
+			continue
+		}
+		fileName := fmt.Sprintf("%s-%s.jar", c.artifact, version)
+		versions = append(versions, ModVersion{
+			ID:          version,
+			ProjectID:   c.id(),
+			Version:     version,
+			Channel:     mavenChannel(version),
+			FileName:    fileName,
+			Loaders:     namedLoaders(c.artifact + " " + version),
+			DownloadURL: fmt.Sprintf("%s/%s/%s/%s", repo, c.path(), version, fileName),
+		})
+	}
+	return versions
+}
+
+func getVersionMaven(s *Settings, cfg *config.Config, id, version string) (ModVersion, error) {
+	logger.Log.Printf("Getting version %s for Maven mod: %s", version, id)
+	versions, err := getModVersionsMaven(s, cfg, id)
+	if err != nil {
+		return ModVersion{}, err
+	}
+	for _, v := range versions {
+		if v.ID == version {
+			return v, nil
+		}
+	}
+	logger.Log.Printf("Could not find version: %s", version)
+	return ModVersion{}, fmt.Errorf("could not find version: %s", version)
+}
+
+// mavenMinecraftVersion finds Minecraft versions written as build metadata
+// or with an "mc" prefix, such as 1.4.2+1.20.1 or 1.4.2-mc1.20.1.
+var mavenMinecraftVersion = regexp.MustCompile(`(?i)(?:\+|mc)(1\.\d+(?:\.\d+)?)`)
+
+// mavenVersionCompatible filters out snapshots, versions for another
+// Minecraft version and artifacts for another loader. Versions that do not
+// name a Minecraft version or loader are kept.
+func mavenVersionCompatible(cfg *config.Config, artifact, version string) bool {
+	if strings.HasSuffix(version, "-SNAPSHOT") {
+		return false
+	}
+	if loaders := namedLoaders(artifact + " " + version); len(loaders) > 0 && !acceptsLoaders(cfg, loaders) {
+		return false
+	}
+	if m := mavenMinecraftVersion.FindStringSubmatch(version); m != nil && m[1] != cfg.Minecraft {
+		return false
+	}
+	return true
+}
+
+func mavenChannel(version string) string {
+	v := strings.ToLower(version)
+	switch {
+	case strings.Contains(v, "alpha"):
+		return ChannelAlpha
+	case strings.Contains(v, "beta"), strings.Contains(v, "-rc"), strings.Contains(v, "-pre"):
+		return ChannelBeta
+	}
+	return ChannelRelease
+}
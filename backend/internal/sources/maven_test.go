@@ -0,0 +1,126 @@
+package sources
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+const mavenTestMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>dev.example</groupId>
+  <artifactId>examplemod-fabric</artifactId>
+  <versioning>
+    <latest>1.4.0-SNAPSHOT</latest>
+    <release>1.3.0-beta+1.20.1</release>
+    <versions>
+      <version>1.0.0+1.19.2</version>
+      <version>1.1.0</version>
+      <version>1.2.0-mc1.20.1</version>
+      <version>1.3.0-beta+1.20.1</version>
+      <version>1.4.0-SNAPSHOT</version>
+    </versions>
+    <lastUpdated>20240101000000</lastUpdated>
+  </versioning>
+</metadata>`
+
+// newMavenTestServer serves the artifact from the second of two
+// repositories, the first one does not have it.
+func newMavenTestServer(t *testing.T) (*httptest.Server, *Settings) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/dev/example/examplemod-fabric/maven-metadata.xml":
+			io.WriteString(w, mavenTestMetadata)
+		case "/broken/dev/example/examplemod-fabric/maven-metadata.xml":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+	s.MavenRepos = []string{srv.URL + "/snapshots", srv.URL + "/releases"}
+	return srv, s
+}
+
+func TestMavenGetModVersions(t *testing.T) {
+	srv, s := newMavenTestServer(t)
+
+	versions, err := GetModVersions(s, testConfig("1.20.1", "fabric"), "dev.example:examplemod-fabric", "maven")
+	if err != nil {
+		t.Fatalf("GetModVersions() error = %v", err)
+	}
+	var ids []string
+	for _, v := range versions {
+		ids = append(ids, v.ID)
+	}
+	// Newest first, without snapshots or versions for other Minecraft
+	// versions.
+	if want := []string{"1.3.0-beta+1.20.1", "1.2.0-mc1.20.1", "1.1.0"}; !slices.Equal(ids, want) {
+		t.Fatalf("versions = %v, want %v", ids, want)
+	}
+
+	v := versions[0]
+	if v.Channel != ChannelBeta || v.ProjectID != "dev.example:examplemod-fabric" || !slices.Equal(v.Loaders, []string{"fabric"}) {
+		t.Errorf("first version = %+v", v)
+	}
+	wantURL := srv.URL + "/releases/dev/example/examplemod-fabric/1.3.0-beta+1.20.1/examplemod-fabric-1.3.0-beta+1.20.1.jar"
+	if v.FileName != "examplemod-fabric-1.3.0-beta+1.20.1.jar" || v.DownloadURL != wantURL {
+		t.Errorf("file = %s at %s, want %s", v.FileName, v.DownloadURL, wantURL)
+	}
+
+	latest, err := GetLatestVersion(s, testConfig("1.20.1", "fabric"), "dev.example:examplemod-fabric", "maven", ChannelRelease)
+	if err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+	if latest.ID != "1.2.0-mc1.20.1" {
+		t.Errorf("GetLatestVersion(release) = %s, want 1.2.0-mc1.20.1", latest.ID)
+	}
+
+	// The artifact names its loader, so a Forge project gets no versions.
+	versions, err = GetModVersions(s, testConfig("1.20.1", "forge"), "dev.example:examplemod-fabric", "maven")
+	if err != nil || len(versions) != 0 {
+		t.Errorf("GetModVersions() for forge = %v, %v, want none", versions, err)
+	}
+}
+
+func TestMavenSearchMods(t *testing.T) {
+	_, s := newMavenTestServer(t)
+	cfg := testConfig("1.20.1", "fabric")
+
+	result, err := SearchMods(s, cfg, "dev.example:examplemod-fabric:1.1.0", "maven", SearchOptions{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("SearchMods() error = %v", err)
+	}
+	if len(result.Hits) != 1 {
+		t.Fatalf("SearchMods() returned %d hits, want 1", len(result.Hits))
+	}
+	hit := result.Hits[0]
+	if hit.ID != "dev.example:examplemod-fabric" || len(hit.Versions) != 1 || hit.Versions[0].ID != "1.1.0" {
+		t.Errorf("hit = %+v, want only the requested version", hit)
+	}
+
+	if _, err := SearchMods(s, cfg, "examplemod", "maven", SearchOptions{Page: 1, PageSize: 20}); err == nil {
+		t.Error("SearchMods() accepted a query that is not Maven coordinates")
+	}
+	if _, err := SearchMods(s, cfg, "dev.example:missing", "maven", SearchOptions{Page: 1, PageSize: 20}); err == nil {
+		t.Error("SearchMods() found an artifact that no repository has")
+	}
+}
+
+func TestMavenRepositoryError(t *testing.T) {
+	srv, s := newMavenTestServer(t)
+	// A failing repository stops the lookup rather than being skipped like
+	// one that does not have the artifact.
+	s.MavenRepos = []string{srv.URL + "/broken", srv.URL + "/releases"}
+
+	_, err := GetModVersions(s, testConfig("1.20.1", "fabric"), "dev.example:examplemod-fabric", "maven")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("GetModVersions() error = %v, want a 500 StatusError", err)
+	}
+}
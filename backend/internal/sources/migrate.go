@@ -0,0 +1,95 @@
+package sources
+
+import (
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/util"
+)
+
+// BackfillIDs looks up the project and version IDs of mods that were added
+// before they were recorded. The stored version is matched by download URL,
+// then by filename and finally by version number. Mods whose versions were
+// listed but did not match are marked unresolved and not looked up again,
+// mods that could not be looked up are retried next time. cfg is left
+// untouched, the changed mods are returned keyed by mod ID.
+func BackfillIDs(s *Settings, cfg *config.Config) map[string]config.Mod {
+	type job struct {
+		modID string
+		mod   config.Mod
+	}
+	type result struct {
+		modID string
+		mod   config.Mod
+		ok    bool
+	}
+
+	processJob := func(j job) result {
+		versions, err := GetModVersions(s, cfg, j.modID, j.mod.Platform)
+		if err != nil {
+			logger.Log.Printf("Error getting versions to backfill IDs for mod %s: %v", j.modID, err)
+			return result{modID: j.modID}
+		}
+
+		v, ok := matchStoredVersion(j.mod, versions)
+		if !ok {
+			logger.Log.Printf("Could not match stored version %s of mod %s, marking it unresolved", j.mod.Version, j.modID)
+			j.mod.Unresolved = true
+			return result{modID: j.modID, mod: j.mod, ok: true}
+		}
+		if j.mod.ProjectID == "" {
+			j.mod.ProjectID = v.ProjectID
+		}
+		j.mod.VersionID = v.ID
+		logger.Log.Printf("Backfilled IDs for mod %s: project %s, version %s", j.modID, j.mod.ProjectID, j.mod.VersionID)
+		return result{modID: j.modID, mod: j.mod, ok: true}
+	}
+
+	var pending []job
+	for modID, mod := range cfg.Mods {
+		if mod.ProjectID != "" && mod.VersionID != "" || mod.Unresolved {
+			continue
+		}
+		if !HasProvider(mod.Platform) {
+			continue
+		}
+		pending = append(pending, job{modID: modID, mod: mod})
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	logger.Log.Printf("Backfilling IDs for %d mods", len(pending))
+
+	jobs := make(chan job)
+	results := util.WorkerPool(jobs, processJob, util.Workers(len(pending)))
+
+	go func() {
+		for _, j := range pending {
+			jobs <- j
+		}
+		close(jobs)
+	}()
+
+	changed := map[string]config.Mod{}
+	for r := range results {
+		if r.ok {
+			changed[r.modID] = r.mod
+		}
+	}
+	return changed
+}
+
+func matchStoredVersion(mod config.Mod, versions []ModVersion) (ModVersion, bool) {
+	matchers := []func(ModVersion) bool{
+		func(v ModVersion) bool { return mod.URL != "" && v.DownloadURL == mod.URL },
+		func(v ModVersion) bool { return mod.Filename != "" && v.FileName == mod.Filename },
+		func(v ModVersion) bool { return mod.Version != "" && v.Version == mod.Version },
+	}
+	for _, match := range matchers {
+		for _, v := range versions {
+			if match(v) {
+				return v, true
+			}
+		}
+	}
+	return ModVersion{}, false
+}
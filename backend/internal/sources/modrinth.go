@@ -1,19 +1,55 @@
 package sources
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
+	"maps"
 	"net/url"
 	"slices"
 	"strconv"
-	"sync"
 
 	"github.com/sqot0/packsmith/backend/internal/config"
 	"github.com/sqot0/packsmith/backend/internal/logger"
-	"github.com/sqot0/packsmith/backend/internal/util"
 )
 
+func init() {
+	Register(modrinthProvider{})
+}
+
+type modrinthProvider struct{}
+
+func (modrinthProvider) Name() string { return "modrinth" }
+
+func (modrinthProvider) SearchMods(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	return searchModsModrinth(s, cfg, query, opts)
+}
+
+func (modrinthProvider) GetModVersions(s *Settings, cfg *config.Config, modID string) ([]ModVersion, error) {
+	return getModVersionsModrinth(s, cfg, projectRef(cfg, modID))
+}
+
+func (modrinthProvider) GetVersion(s *Settings, cfg *config.Config, modID, versionID string) (ModVersion, error) {
+	return getVersionModrinth(s, versionID)
+}
+
+func (modrinthProvider) GetLatestVersion(s *Settings, cfg *config.Config, modID, channel string) (ModVersion, error) {
+	return getLatestVersionModrinth(s, cfg, projectRef(cfg, modID), channel)
+}
+
+func (modrinthProvider) GetModDetails(s *Settings, cfg *config.Config, modID string) (*ModDetails, error) {
+	return getModDetailsModrinth(s, projectRef(cfg, modID))
+}
+
+func (modrinthProvider) GetIncompatibilities(s *Settings, cfg *config.Config, modID, versionID string) ([]Incompatibility, error) {
+	return getIncompatibilitiesModrinth(s, versionID)
+}
+
+func (modrinthProvider) GetDependencies(s *Settings, cfg *config.Config, modID, versionID string) ([]Dependency, error) {
+	return getDependenciesModrinth(s, cfg, versionID)
+}
+
 type ModrinthSearchMod struct {
 	Slug, Title, Description string
 	ClientSide               string `json:"client_side"`
@@ -22,202 +58,561 @@ type ModrinthSearchMod struct {
 }
 
 type ModrinthSearch struct {
-	Hits []ModrinthSearchMod
+	Hits      []ModrinthSearchMod
+	TotalHits int `json:"total_hits"`
+}
+
+type ModrinthProject struct {
+	ID          string   `json:"id"`
+	Slug        string   `json:"slug"`
+	Title       string   `json:"title"`
+	ClientSide  string   `json:"client_side"`
+	ServerSide  string   `json:"server_side"`
+	Description string   `json:"description"`
+	Body        string   `json:"body"`
+	IconURL     string   `json:"icon_url"`
+	Categories  []string `json:"categories"`
+	SourceURL   string   `json:"source_url"`
+	IssuesURL   string   `json:"issues_url"`
+	WikiURL     string   `json:"wiki_url"`
+	Downloads   int64    `json:"downloads"`
+	Followers   int64    `json:"followers"`
+	Updated     string   `json:"updated"`
+	License     struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"license"`
+	Gallery []struct {
+		URL         string `json:"url"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Featured    bool   `json:"featured"`
+	} `json:"gallery"`
+}
+
+type ModrinthTeamMember struct {
+	Role string `json:"role"`
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+type ModrinthDependency struct {
+	VersionID      string `json:"version_id"`
+	ProjectID      string `json:"project_id"`
+	FileName       string `json:"file_name"`
+	DependencyType string `json:"dependency_type"`
 }
 
 type ModrinthModVersion struct {
-	GameVersions []string `json:"game_versions"`
-	Loaders      []string `json:"loaders"`
-	Version      string   `json:"version_number"`
-	Files        []struct {
-		URL     string `json:"url"`
-		Primary bool   `json:"primary"`
+	ID            string               `json:"id"`
+	ProjectID     string               `json:"project_id"`
+	GameVersions  []string             `json:"game_versions"`
+	Loaders       []string             `json:"loaders"`
+	Version       string               `json:"version_number"`
+	VersionType   string               `json:"version_type"`
+	DatePublished string               `json:"date_published"`
+	Changelog     string               `json:"changelog"`
+	Dependencies  []ModrinthDependency `json:"dependencies"`
+	Files         []struct {
+		URL      string `json:"url"`
+		Filename string `json:"filename"`
+		Size     int64  `json:"size"`
+		Primary  bool   `json:"primary"`
 	}
 }
 
-func searchModsModrinth(cfg *config.Config, query string) ([]ModSearch, error) {
-	logger.Log.Printf("Searching Modrinth for query: %s", query)
-	params := url.Values{
-		"query":  {query},
-		"limit":  {"5"},
-		"facets": {fmt.Sprintf(`[["project_type:mod"],["versions:%s"],["categories:%s"]]`, cfg.Minecraft, cfg.Loader)},
-	}
+func (v ModrinthModVersion) compatible(cfg *config.Config) bool {
+	return slices.Contains(v.GameVersions, cfg.Minecraft) && acceptsLoaders(cfg, v.Loaders)
+}
 
-	req, _ := http.NewRequest("GET", "https://api.modrinth.com/v2/search?"+params.Encode(), nil)
-	setHeadersForRequest(req)
+func (v ModrinthModVersion) toModVersion() ModVersion {
+	mv := ModVersion{
+		ID:           v.ID,
+		ProjectID:    v.ProjectID,
+		Version:      v.Version,
+		Channel:      v.VersionType,
+		Published:    v.DatePublished,
+		GameVersions: v.GameVersions,
+		Loaders:      v.Loaders,
+		Changelog:    v.Changelog,
+	}
+	for _, f := range v.Files {
+		if f.Primary {
+			mv.FileName = f.Filename
+			mv.FileSize = f.Size
+			mv.DownloadURL = f.URL
+		}
+	}
+	return mv
+}
 
-	logger.Log.Printf("Making HTTP request to Modrinth search API")
-	resp, err := http.DefaultClient.Do(req)
+func searchModsModrinth(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	logger.Log.Printf("Searching Modrinth for query: %s, page: %d", query, opts.Page)
+	facets := [][]string{
+		{"project_type:mod"},
+		{"versions:" + cfg.Minecraft},
+		{},
+	}
+	// Facets in one group are ORed, so mods for any accepted loader match.
+	for _, loader := range acceptedLoaders(cfg) {
+		facets[2] = append(facets[2], "categories:"+loader)
+	}
+	for _, category := range opts.Categories {
+		facets = append(facets, []string{"categories:" + category})
+	}
+	if opts.ClientSide != "" {
+		facets = append(facets, []string{"client_side:" + opts.ClientSide})
+	}
+	if opts.ServerSide != "" {
+		facets = append(facets, []string{"server_side:" + opts.ServerSide})
+	}
+	facetsJSON, err := json.Marshal(facets)
 	if err != nil {
-		logger.Log.Printf("Error making request: %v", err)
+		logger.Log.Printf("Error encoding facets: %v", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var data ModrinthSearch
+	params := url.Values{
+		"query":  {query},
+		"limit":  {strconv.Itoa(opts.PageSize)},
+		"offset": {strconv.Itoa(opts.offset())},
+		"index":  {opts.Sort},
+		"facets": {string(facetsJSON)},
+	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		logger.Log.Printf("Error decoding JSON response: %v", err)
+	var data ModrinthSearch
+	if err := fetchModrinthJSON(s, "/search?"+params.Encode(), &data); err != nil {
 		return nil, err
 	}
 
-	var mu sync.Mutex
 	mods := make([]ModSearch, 0, len(data.Hits))
+	for _, h := range data.Hits {
+		mods = append(mods, ModSearch{
+			ID: h.Slug, Name: h.Title, Description: h.Description,
+			ClientSide: h.ClientSide, ServerSide: h.ServerSide,
+			Downloads: strconv.Itoa(h.Downloads),
+			URL:       "https://modrinth.com/mod/" + h.Slug,
+		})
+	}
 
-	processMod := func(h ModrinthSearchMod) error {
-		versionsUrl := fmt.Sprintf("https://api.modrinth.com/v2/project/%s/version", h.Slug)
-		req, _ := http.NewRequest("GET", versionsUrl, nil)
-		setHeadersForRequest(req)
+	err = fillVersions(mods, func(id string) ([]ModVersion, error) {
+		return getModVersionsModrinth(s, cfg, id)
+	})
+	if err != nil {
+		logger.Log.Printf("Error processing mod: %v", err)
+		return nil, err
+	}
 
-		resp, err := http.DefaultClient.Do(req)
+	logger.Log.Printf("Found %d mods on Modrinth, %d total", len(mods), data.TotalHits)
+	return &SearchResult{Hits: mods, TotalHits: data.TotalHits, Page: opts.Page, PageSize: opts.PageSize}, nil
+}
+
+func fetchModrinthJSON(s *Settings, endpoint string, out any) error {
+	return doModrinthJSON(s, "GET", endpoint, nil, out)
+}
+
+func postModrinthJSON(s *Settings, endpoint string, body, out any) error {
+	return doModrinthJSON(s, "POST", endpoint, body, out)
+}
+
+func doModrinthJSON(s *Settings, method, endpoint string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
 		if err != nil {
-			logger.Log.Printf("Error making request for versions: %v", err)
+			logger.Log.Printf("Error encoding request body: %v", err)
 			return err
 		}
-		defer resp.Body.Close()
+		reader = bytes.NewReader(data)
+	}
 
-		var versionsData []ModrinthModVersion
+	req, err := s.newRequest(method, s.ModrinthURL+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-		if err := json.NewDecoder(resp.Body).Decode(&versionsData); err != nil {
-			logger.Log.Printf("Error decoding JSON response for versions: %v", err)
-			return err
-		}
+	logger.Log.Printf("Making HTTP request to Modrinth API: %s", endpoint)
+	resp, err := s.do(req)
+	if err != nil {
+		logger.Log.Printf("Error making request: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		logger.Log.Printf("Modrinth API returned status %d", resp.StatusCode)
+		return statusError("modrinth api", resp)
+	}
 
-		var versions []string
-		for _, v := range versionsData {
-			if slices.Contains(v.GameVersions, cfg.Minecraft) &&
-				slices.Contains(v.Loaders, cfg.Loader) {
-				versions = append(versions, v.Version)
-			}
-		}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		logger.Log.Printf("Error decoding JSON response: %v", err)
+		return err
+	}
+	return nil
+}
 
-		mu.Lock()
-		mods = append(mods, ModSearch{
-			ID: h.Slug, Name: h.Title, Description: h.Description,
-			ClientSide: h.ClientSide, ServerSide: h.ServerSide,
-			Downloads: strconv.Itoa(h.Downloads),
-			URL:       "https://modrinth.com/mod/" + h.Slug,
-			Versions:  versions,
-		})
-		mu.Unlock()
-		return nil
+func fetchModrinthVersions(s *Settings, id string) ([]ModrinthModVersion, error) {
+	var versions []ModrinthModVersion
+	if err := fetchModrinthJSON(s, fmt.Sprintf("/project/%s/version", id), &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func fetchModrinthVersion(s *Settings, versionID string) (ModrinthModVersion, error) {
+	var version ModrinthModVersion
+	if err := fetchModrinthJSON(s, "/version/"+versionID, &version); err != nil {
+		return ModrinthModVersion{}, err
+	}
+	return version, nil
+}
+
+func getVersionModrinth(s *Settings, versionID string) (ModVersion, error) {
+	logger.Log.Printf("Getting Modrinth version: %s", versionID)
+	v, err := fetchModrinthVersion(s, versionID)
+	if err != nil {
+		return ModVersion{}, err
 	}
+	mv := v.toModVersion()
+	if mv.DownloadURL == "" {
+		logger.Log.Printf("No primary file for version: %s", versionID)
+		return ModVersion{}, fmt.Errorf("no primary file for version %s", versionID)
+	}
+	return mv, nil
+}
 
-	jobs := make(chan ModrinthSearchMod, len(data.Hits))
-	results := util.WorkerPool(jobs, processMod, len(data.Hits))
+func getModVersionsModrinth(s *Settings, cfg *config.Config, id string) ([]ModVersion, error) {
+	versions, err := fetchModrinthVersions(s, id)
+	if err != nil {
+		return nil, err
+	}
 
-	go func() {
-		for _, hit := range data.Hits {
-			jobs <- hit
+	var compatibleVersions []ModVersion
+	for _, v := range versions {
+		if v.compatible(cfg) {
+			compatibleVersions = append(compatibleVersions, v.toModVersion())
 		}
-		close(jobs)
-	}()
+	}
+	logger.Log.Printf("Found %d compatible versions", len(compatibleVersions))
+	return compatibleVersions, nil
+}
+
+func getLatestVersionModrinth(s *Settings, cfg *config.Config, id, channel string) (ModVersion, error) {
+	logger.Log.Printf("Getting latest version for Modrinth mod: %s", id)
+	versions, err := getModVersionsModrinth(s, cfg, id)
+	if err != nil {
+		return ModVersion{}, err
+	}
+	return latestVersion(cfg, versions, channel)
+}
+
+func getDependenciesModrinth(s *Settings, cfg *config.Config, versionID string) ([]Dependency, error) {
+	logger.Log.Printf("Getting dependencies for Modrinth version: %s", versionID)
+	version, err := fetchModrinthVersion(s, versionID)
+	if err != nil {
+		return nil, err
+	}
 
-	for err := range results {
+	var deps []Dependency
+	for _, d := range version.Dependencies {
+		if d.DependencyType != "required" {
+			continue
+		}
+		// Dependencies hosted outside Modrinth are only named by their file.
+		if d.ProjectID == "" && d.VersionID == "" {
+			logger.Log.Printf("Dependency %q is not on Modrinth", d.FileName)
+			deps = append(deps, Dependency{ModID: d.FileName, Name: d.FileName, FileName: d.FileName, Unresolved: "not hosted on Modrinth, add it by hand"})
+			continue
+		}
+		dep, err := resolveDependencyModrinth(s, cfg, d)
 		if err != nil {
-			logger.Log.Printf("Error processing mod: %v", err)
+			logger.Log.Printf("Error resolving dependency %s: %v", d.ProjectID, err)
 			return nil, err
 		}
+		deps = append(deps, dep)
 	}
-
-	logger.Log.Printf("Found %d mods on Modrinth", len(mods))
-	return mods, nil
+	logger.Log.Printf("Found %d required dependencies for version: %s", len(deps), versionID)
+	return deps, nil
 }
 
-func getDownloadURLModrinth(cfg *config.Config, id, version string) (string, error) {
-	logger.Log.Printf("Getting download URL for Modrinth mod: %s", id)
-	req, _ := http.NewRequest("GET",
-		fmt.Sprintf("https://api.modrinth.com/v2/project/%s/version", id), nil)
-	setHeadersForRequest(req)
+// resolveDependencyModrinth picks the pinned dependency version when it is
+// compatible with the project, or the latest compatible version allowed by
+// the project channel otherwise. A dependency without a usable version is
+// returned unresolved.
+func resolveDependencyModrinth(s *Settings, cfg *config.Config, d ModrinthDependency) (Dependency, error) {
+	projectID := d.ProjectID
+	if projectID == "" {
+		pinned, err := fetchModrinthVersion(s, d.VersionID)
+		if err != nil {
+			return Dependency{}, err
+		}
+		projectID = pinned.ProjectID
+	}
+
+	var project ModrinthProject
+	if err := fetchModrinthJSON(s, "/project/"+projectID, &project); err != nil {
+		return Dependency{}, err
+	}
 
-	logger.Log.Printf("Making HTTP request to Modrinth version API")
-	resp, err := http.DefaultClient.Do(req)
+	versions, err := fetchModrinthVersions(s, projectID)
 	if err != nil {
-		logger.Log.Printf("Error making request: %v", err)
-		return "", err
+		return Dependency{}, err
 	}
-	defer resp.Body.Close()
 
-	var versions []ModrinthModVersion
+	channel := cfg.ModChannel(config.Mod{})
+	var chosen *ModrinthModVersion
+	for i, v := range versions {
+		if !v.compatible(cfg) {
+			continue
+		}
+		if v.ID == d.VersionID {
+			chosen = &versions[i]
+			break
+		}
+		if chosen == nil && ChannelAllows(channel, v.VersionType) {
+			chosen = &versions[i]
+		}
+	}
+	dep := Dependency{
+		ModID:     project.Slug,
+		ProjectID: project.ID,
+		Name:      project.Title,
+		Source:    "https://modrinth.com/mod/" + project.Slug,
+	}
+	if chosen == nil {
+		logger.Log.Printf("No compatible version found for dependency: %s", project.Slug)
+		dep.Unresolved = ErrNoCompatibleVersion.Error()
+		return dep, nil
+	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
-		logger.Log.Printf("Error decoding JSON response: %v", err)
-		return "", err
+	mv := chosen.toModVersion()
+	if mv.DownloadURL == "" {
+		logger.Log.Printf("No primary file for dependency: %s", project.Slug)
+		dep.Unresolved = fmt.Sprintf("version %s has no primary file", mv.Version)
+		return dep, nil
 	}
 
-	for _, v := range versions {
-		if v.Version == version && slices.Contains(v.GameVersions, cfg.Minecraft) &&
-			slices.Contains(v.Loaders, cfg.Loader) {
-			for _, f := range v.Files {
-				if f.Primary {
-					logger.Log.Printf("Download URL obtained: %s, version: %s", f.URL, v.Version)
-					return f.URL, nil
+	logger.Log.Printf("Resolved dependency %s to version: %s", project.Slug, chosen.Version)
+	dep.Version = mv.Version
+	dep.VersionID = mv.ID
+	dep.FileName = mv.FileName
+	dep.URL = mv.DownloadURL
+	return dep, nil
+}
+
+func getIncompatibilitiesModrinth(s *Settings, versionID string) ([]Incompatibility, error) {
+	logger.Log.Printf("Getting incompatibilities for Modrinth version: %s", versionID)
+	version, err := fetchModrinthVersion(s, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var incompatibilities []Incompatibility
+	for _, d := range version.Dependencies {
+		if d.DependencyType != "incompatible" {
+			continue
+		}
+
+		var inc Incompatibility
+		projectID := d.ProjectID
+		if d.VersionID != "" {
+			inc.VersionID = d.VersionID
+			if projectID == "" {
+				pinned, err := fetchModrinthVersion(s, d.VersionID)
+				if err != nil {
+					return nil, err
 				}
+				projectID = pinned.ProjectID
 			}
 		}
+
+		var project ModrinthProject
+		if err := fetchModrinthJSON(s, "/project/"+projectID, &project); err != nil {
+			return nil, err
+		}
+		inc.ModID = project.Slug
+		inc.ProjectID = project.ID
+		incompatibilities = append(incompatibilities, inc)
 	}
-	logger.Log.Println("No compatible version found")
-	return "", fmt.Errorf("no compatible version found")
+	logger.Log.Printf("Found %d incompatibilities for version: %s", len(incompatibilities), versionID)
+	return incompatibilities, nil
 }
 
-func getModVersionsModrinth(cfg *config.Config, id string) ([]string, error) {
-	req, _ := http.NewRequest("GET",
-		fmt.Sprintf("https://api.modrinth.com/v2/project/%s/version", id), nil)
-	setHeadersForRequest(req)
+// IdentifyFiles matches files by their SHA-1 hash, then by their SHA-512
+// hash for the files that were not found.
+func (modrinthProvider) IdentifyFiles(s *Settings, cfg *config.Config, files []FileHashes) (map[int]IdentifiedFile, error) {
+	return identifyFilesModrinth(s, files)
+}
+
+func identifyFilesModrinth(s *Settings, files []FileHashes) (map[int]IdentifiedFile, error) {
+	versions := map[int]ModrinthModVersion{}
+	for _, algorithm := range []string{"sha1", "sha512"} {
+		byHash := map[string]int{}
+		for i, f := range files {
+			hash := f.SHA1
+			if algorithm == "sha512" {
+				hash = f.SHA512
+			}
+			if _, found := versions[i]; !found && hash != "" {
+				byHash[hash] = i
+			}
+		}
+		if len(byHash) == 0 {
+			continue
+		}
 
-	logger.Log.Printf("Making HTTP request to Modrinth version API")
-	resp, err := http.DefaultClient.Do(req)
+		logger.Log.Printf("Looking up %d %s hashes on Modrinth", len(byHash), algorithm)
+		body := map[string]any{"hashes": slices.Collect(maps.Keys(byHash)), "algorithm": algorithm}
+		var data map[string]ModrinthModVersion
+		if err := postModrinthJSON(s, "/version_files", body, &data); err != nil {
+			return nil, err
+		}
+		for hash, v := range data {
+			if i, ok := byHash[hash]; ok {
+				versions[i] = v
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	projectIDs := make([]string, 0, len(versions))
+	for _, v := range versions {
+		if !slices.Contains(projectIDs, v.ProjectID) {
+			projectIDs = append(projectIDs, v.ProjectID)
+		}
+	}
+	idsJSON, err := json.Marshal(projectIDs)
 	if err != nil {
-		logger.Log.Printf("Error making request: %v", err)
+		logger.Log.Printf("Error encoding project IDs: %v", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var versions []ModrinthModVersion
-
-	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
-		logger.Log.Printf("Error decoding JSON response: %v", err)
+	var projects []ModrinthProject
+	if err := fetchModrinthJSON(s, "/projects?"+url.Values{"ids": {string(idsJSON)}}.Encode(), &projects); err != nil {
 		return nil, err
 	}
-	var compatibleVersions []string
-	for _, v := range versions {
-		if slices.Contains(v.GameVersions, cfg.Minecraft) &&
-			slices.Contains(v.Loaders, cfg.Loader) {
-			compatibleVersions = append(compatibleVersions, v.Version)
+
+	identified := map[int]IdentifiedFile{}
+	for i, v := range versions {
+		idx := slices.IndexFunc(projects, func(p ModrinthProject) bool { return p.ID == v.ProjectID })
+		if idx == -1 {
+			logger.Log.Printf("Could not find Modrinth project: %s", v.ProjectID)
+			continue
+		}
+		project := projects[idx]
+		identified[i] = IdentifiedFile{
+			ModID:    project.Slug,
+			Name:     project.Title,
+			Platform: "modrinth",
+			Source:   "https://modrinth.com/mod/" + project.Slug,
+			Side:     sideFromSupport(project.ClientSide, project.ServerSide),
+			Version:  v.toModVersion(),
 		}
 	}
-	logger.Log.Printf("Found %d compatible versions", len(compatibleVersions))
-	return compatibleVersions, nil
+	return identified, nil
 }
 
-func getLatestVersionModrinth(cfg *config.Config, id string) (string, error) {
-	logger.Log.Printf("Getting latest version for Modrinth mod: %s", id)
-	req, _ := http.NewRequest("GET",
-		fmt.Sprintf("https://api.modrinth.com/v2/project/%s/version", id), nil)
-	setHeadersForRequest(req)
+// CheckUpdates asks Modrinth for the newest version of every file in one
+// request per channel policy, keyed on the SHA-1 hash of the installed jar.
+func (modrinthProvider) CheckUpdates(s *Settings, cfg *config.Config, queries map[string]UpdateQuery) (map[string]ModVersion, error) {
+	return checkUpdatesModrinth(s, cfg, queries)
+}
 
-	logger.Log.Printf("Making HTTP request to Modrinth version API")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		logger.Log.Printf("Error making request: %v", err)
-		return "", err
+func checkUpdatesModrinth(s *Settings, cfg *config.Config, queries map[string]UpdateQuery) (map[string]ModVersion, error) {
+	byChannel := map[string]map[string]string{}
+	for modID, q := range queries {
+		if q.Hashes.SHA1 == "" {
+			continue
+		}
+		if byChannel[q.Channel] == nil {
+			byChannel[q.Channel] = map[string]string{}
+		}
+		byChannel[q.Channel][q.Hashes.SHA1] = modID
 	}
-	defer resp.Body.Close()
 
-	var versions []ModrinthModVersion
+	latest := map[string]ModVersion{}
+	for channel, byHash := range byChannel {
+		var versionTypes []string
+		for _, t := range []string{ChannelRelease, ChannelBeta, ChannelAlpha} {
+			if ChannelAllows(channel, t) {
+				versionTypes = append(versionTypes, t)
+			}
+		}
 
-	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
-		logger.Log.Printf("Error decoding JSON response: %v", err)
-		return "", err
+		logger.Log.Printf("Looking up updates for %d %s files on Modrinth", len(byHash), channel)
+		body := map[string]any{
+			"hashes":        slices.Collect(maps.Keys(byHash)),
+			"algorithm":     "sha1",
+			"loaders":       acceptedLoaders(cfg),
+			"game_versions": []string{cfg.Minecraft},
+			"version_types": versionTypes,
+		}
+		var data map[string]ModrinthModVersion
+		if err := postModrinthJSON(s, "/version_files/update", body, &data); err != nil {
+			return nil, err
+		}
+		for hash, v := range data {
+			modID, ok := byHash[hash]
+			if !ok || !v.compatible(cfg) || !ChannelAllows(channel, v.VersionType) {
+				continue
+			}
+			latest[modID] = v.toModVersion()
+		}
 	}
+	logger.Log.Printf("Modrinth returned updates for %d of %d files", len(latest), len(queries))
+	return latest, nil
+}
 
-	for _, v := range versions {
-		if slices.Contains(v.GameVersions, cfg.Minecraft) &&
-			slices.Contains(v.Loaders, cfg.Loader) {
-			logger.Log.Printf("Latest version found: %s", v.Version)
-			return v.Version, nil
+func getModDetailsModrinth(s *Settings, id string) (*ModDetails, error) {
+	logger.Log.Printf("Getting details for Modrinth mod: %s", id)
+	var project ModrinthProject
+	if err := fetchModrinthJSON(s, "/project/"+url.PathEscape(id), &project); err != nil {
+		return nil, err
+	}
+	var members []ModrinthTeamMember
+	if err := fetchModrinthJSON(s, fmt.Sprintf("/project/%s/members", url.PathEscape(id)), &members); err != nil {
+		return nil, err
+	}
+
+	license := project.License.Name
+	if license == "" {
+		license = project.License.ID
+	}
+	details := &ModDetails{
+		ID:          project.Slug,
+		ProjectID:   project.ID,
+		Name:        project.Title,
+		Description: project.Description,
+		IconURL:     project.IconURL,
+		License:     license,
+		LicenseURL:  project.License.URL,
+		Categories:  project.Categories,
+		URL:         "https://modrinth.com/mod/" + project.Slug,
+		SourceURL:   project.SourceURL,
+		IssuesURL:   project.IssuesURL,
+		WikiURL:     project.WikiURL,
+		Downloads:   project.Downloads,
+		Followers:   project.Followers,
+		Updated:     project.Updated,
+		Body:        project.Body,
+		BodyFormat:  BodyMarkdown,
+	}
+	for _, m := range members {
+		details.Authors = append(details.Authors, m.User.Username)
+	}
+	// Featured images are shown first.
+	for _, featured := range []bool{true, false} {
+		for _, img := range project.Gallery {
+			if img.Featured == featured {
+				details.Gallery = append(details.Gallery, GalleryImage{URL: img.URL, Title: img.Title, Description: img.Description})
+			}
 		}
 	}
-	logger.Log.Println("No compatible version found")
-	return "", fmt.Errorf("no compatible version found")
+	logger.Log.Printf("Got details for Modrinth mod: %s", id)
+	return details, nil
 }
@@ -0,0 +1,286 @@
+package sources
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+type modrinthTestFile struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	Primary  bool   `json:"primary"`
+}
+
+func modrinthTestVersion(id, number, channel, minecraft string, loaders ...string) map[string]any {
+	return map[string]any{
+		"id":             id,
+		"project_id":     "AANobbMI",
+		"version_number": number,
+		"version_type":   channel,
+		"game_versions":  []string{minecraft},
+		"loaders":        loaders,
+		"files": []modrinthTestFile{
+			{URL: "https://cdn.modrinth.com/" + id + "-sources.jar", Filename: id + "-sources.jar"},
+			{URL: "https://cdn.modrinth.com/" + id + ".jar", Filename: id + ".jar", Size: 1024, Primary: true},
+		},
+	}
+}
+
+// modrinthVersions is the version list of a project, newest first.
+var modrinthVersions = []map[string]any{
+	modrinthTestVersion("v6", "0.6.0-alpha", ChannelAlpha, "1.20.1", "fabric", "quilt"),
+	modrinthTestVersion("v5", "0.5.3-beta", ChannelBeta, "1.20.1", "fabric"),
+	modrinthTestVersion("v4", "0.5.2", ChannelRelease, "1.20.4", "fabric"),
+	modrinthTestVersion("v3", "0.5.1", ChannelRelease, "1.20.1", "forge"),
+	modrinthTestVersion("v2", "0.5.0", ChannelRelease, "1.20.1", "fabric"),
+	modrinthTestVersion("v1", "0.4.0", ChannelRelease, "1.20.1", "quilt"),
+}
+
+func newModrinthServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /project/sodium/version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, modrinthVersions)
+	})
+	mux.HandleFunc("GET /version/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for _, v := range modrinthVersions {
+			if v["id"] == r.PathValue("id") {
+				writeJSON(t, w, v)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func versionIDs(versions []ModVersion) []string {
+	ids := make([]string, 0, len(versions))
+	for _, v := range versions {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+func TestModrinthGetModVersions(t *testing.T) {
+	s := testSettings(newModrinthServer(t))
+
+	tests := []struct {
+		minecraft, loader string
+		want              []string
+	}{
+		{minecraft: "1.20.1", loader: "fabric", want: []string{"v6", "v5", "v2"}},
+		{minecraft: "1.20.4", loader: "fabric", want: []string{"v4"}},
+		{minecraft: "1.20.1", loader: "forge", want: []string{"v3"}},
+		// Quilt also loads Fabric mods.
+		{minecraft: "1.20.1", loader: "quilt", want: []string{"v6", "v5", "v2", "v1"}},
+		{minecraft: "1.19.2", loader: "fabric", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.minecraft+"/"+tt.loader, func(t *testing.T) {
+			versions, err := GetModVersions(s, testConfig(tt.minecraft, tt.loader), "sodium", "modrinth")
+			if err != nil {
+				t.Fatalf("GetModVersions() error = %v", err)
+			}
+			if got := versionIDs(versions); !slices.Equal(got, tt.want) {
+				t.Errorf("GetModVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModrinthPrimaryFile(t *testing.T) {
+	s := testSettings(newModrinthServer(t))
+
+	v, err := GetVersion(s, testConfig("1.20.1", "fabric"), "sodium", "modrinth", "v2")
+	if err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+	if v.FileName != "v2.jar" || v.DownloadURL != "https://cdn.modrinth.com/v2.jar" || v.FileSize != 1024 {
+		t.Errorf("GetVersion() file = %s %s %d, want the primary file", v.FileName, v.DownloadURL, v.FileSize)
+	}
+	if v.ProjectID != "AANobbMI" || v.Version != "0.5.0" || v.Channel != ChannelRelease {
+		t.Errorf("GetVersion() = %+v", v)
+	}
+
+	if _, err := GetVersion(s, testConfig("1.20.1", "fabric"), "sodium", "modrinth", "missing"); err == nil {
+		t.Error("GetVersion() of a missing version succeeded")
+	}
+}
+
+func TestModrinthGetLatestVersion(t *testing.T) {
+	s := testSettings(newModrinthServer(t))
+
+	tests := []struct {
+		loader, channel string
+		want            string
+		fallback        string
+	}{
+		{loader: "fabric", channel: ChannelRelease, want: "v2"},
+		{loader: "fabric", channel: ChannelBeta, want: "v5"},
+		{loader: "fabric", channel: ChannelAlpha, want: "v6"},
+		// Versions for the project loader win over newer fallback versions.
+		{loader: "quilt", channel: ChannelRelease, want: "v1"},
+		{loader: "quilt", channel: ChannelBeta, want: "v1"},
+		{loader: "quilt", channel: ChannelAlpha, want: "v6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.loader+"/"+tt.channel, func(t *testing.T) {
+			v, err := GetLatestVersion(s, testConfig("1.20.1", tt.loader), "sodium", "modrinth", tt.channel)
+			if err != nil {
+				t.Fatalf("GetLatestVersion() error = %v", err)
+			}
+			if v.ID != tt.want || v.FallbackLoader != tt.fallback {
+				t.Errorf("GetLatestVersion() = %s (fallback %q), want %s (fallback %q)", v.ID, v.FallbackLoader, tt.want, tt.fallback)
+			}
+		})
+	}
+
+	if _, err := GetLatestVersion(s, testConfig("1.19.2", "fabric"), "sodium", "modrinth", ChannelRelease); err == nil {
+		t.Error("GetLatestVersion() without compatible versions succeeded")
+	}
+}
+
+func TestModrinthFallbackLoader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []map[string]any{
+			modrinthTestVersion("v2", "2.0", ChannelRelease, "1.20.1", "fabric"),
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	v, err := GetLatestVersion(testSettings(srv), testConfig("1.20.1", "quilt"), "sodium", "modrinth", ChannelRelease)
+	if err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+	if v.ID != "v2" || v.FallbackLoader != "fabric" {
+		t.Errorf("GetLatestVersion() = %s (fallback %q), want v2 through fabric", v.ID, v.FallbackLoader)
+	}
+}
+
+func TestModrinthSearch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("query") != "sodium" || q.Get("limit") != "5" || q.Get("offset") != "5" || q.Get("index") != SortDownloads {
+			t.Errorf("search parameters = %v", q)
+		}
+		var facets [][]string
+		if err := json.Unmarshal([]byte(q.Get("facets")), &facets); err != nil {
+			t.Errorf("decoding facets: %v", err)
+		}
+		want := [][]string{
+			{"project_type:mod"},
+			{"versions:1.20.1"},
+			{"categories:quilt", "categories:fabric"},
+			{"categories:optimization"},
+			{"client_side:required"},
+		}
+		if !slices.EqualFunc(facets, want, slices.Equal) {
+			t.Errorf("facets = %v, want %v", facets, want)
+		}
+		writeJSON(t, w, map[string]any{
+			"hits": []map[string]any{
+				{"slug": "sodium", "title": "Sodium", "description": "Fast", "client_side": "required", "server_side": "unsupported", "downloads": 42},
+			},
+			"total_hits": 11,
+		})
+	})
+	mux.HandleFunc("GET /project/sodium/version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, modrinthVersions)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	opts := SearchOptions{Page: 2, PageSize: 5, Sort: SortDownloads, Categories: []string{"optimization"}, ClientSide: "required"}
+	result, err := SearchMods(testSettings(srv), testConfig("1.20.1", "quilt"), "sodium", "modrinth", opts)
+	if err != nil {
+		t.Fatalf("SearchMods() error = %v", err)
+	}
+	if result.TotalHits != 11 || result.Page != 2 || result.PageSize != 5 {
+		t.Errorf("SearchMods() = %d hits, page %d, size %d", result.TotalHits, result.Page, result.PageSize)
+	}
+	if len(result.Hits) != 1 {
+		t.Fatalf("SearchMods() returned %d hits, want 1", len(result.Hits))
+	}
+	hit := result.Hits[0]
+	if hit.ID != "sodium" || hit.Name != "Sodium" || hit.Downloads != "42" || hit.URL != "https://modrinth.com/mod/sodium" {
+		t.Errorf("hit = %+v", hit)
+	}
+	if got := versionIDs(hit.Versions); !slices.Equal(got, []string{"v6", "v5", "v2", "v1"}) {
+		t.Errorf("hit versions = %v", got)
+	}
+	for _, v := range hit.Versions {
+		want := ""
+		if !slices.Contains(v.Loaders, "quilt") {
+			want = "fabric"
+		}
+		if v.FallbackLoader != want {
+			t.Errorf("version %s fallback = %q, want %q", v.ID, v.FallbackLoader, want)
+		}
+	}
+}
+
+func TestModrinthCheckUpdates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/version_files/update" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Hashes       []string `json:"hashes"`
+			Algorithm    string   `json:"algorithm"`
+			Loaders      []string `json:"loaders"`
+			GameVersions []string `json:"game_versions"`
+			VersionTypes []string `json:"version_types"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		if body.Algorithm != "sha1" || !slices.Equal(body.Loaders, []string{"fabric"}) || !slices.Equal(body.GameVersions, []string{"1.20.1"}) {
+			t.Errorf("update body = %+v", body)
+		}
+
+		updates := map[string]any{}
+		for _, hash := range body.Hashes {
+			switch hash {
+			case "aaaa":
+				if !slices.Equal(body.VersionTypes, []string{ChannelRelease}) {
+					t.Errorf("release version types = %v", body.VersionTypes)
+				}
+				updates[hash] = modrinthTestVersion("v2", "0.5.0", ChannelRelease, "1.20.1", "fabric")
+			case "bbbb":
+				if !slices.Equal(body.VersionTypes, []string{ChannelRelease, ChannelBeta}) {
+					t.Errorf("beta version types = %v", body.VersionTypes)
+				}
+				updates[hash] = modrinthTestVersion("v5", "0.5.3-beta", ChannelBeta, "1.20.1", "fabric")
+			case "cccc":
+				// Incompatible answers are ignored.
+				updates[hash] = modrinthTestVersion("v3", "0.5.1", ChannelRelease, "1.20.1", "forge")
+			}
+		}
+		writeJSON(t, w, updates)
+	}))
+	t.Cleanup(srv.Close)
+
+	queries := map[string]UpdateQuery{
+		"sodium":  {Hashes: FileHashes{SHA1: "aaaa"}, Channel: ChannelRelease},
+		"lithium": {Hashes: FileHashes{SHA1: "bbbb"}, Channel: ChannelBeta},
+		"forged":  {Hashes: FileHashes{SHA1: "cccc"}, Channel: ChannelRelease},
+		"nohash":  {Channel: ChannelRelease},
+	}
+	latest, err := checkUpdatesModrinth(testSettings(srv), testConfig("1.20.1", "fabric"), queries)
+	if err != nil {
+		t.Fatalf("checkUpdatesModrinth() error = %v", err)
+	}
+	if len(latest) != 2 || latest["sodium"].ID != "v2" || latest["lithium"].ID != "v5" {
+		t.Errorf("checkUpdatesModrinth() = %v", latest)
+	}
+}
@@ -0,0 +1,77 @@
+package sources
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+// Provider is a mod source such as Modrinth or CurseForge. Providers are
+// looked up by Name through the registry, so adding a new source only
+// requires implementing this interface and calling Register.
+//
+// modID is the key the mod is stored under in the project. Providers use the
+// project ID stored for that key where their platform supports it, and
+// versionID is always the platform's immutable version or file ID.
+type Provider interface {
+	Name() string
+	SearchMods(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error)
+	GetModVersions(s *Settings, cfg *config.Config, modID string) ([]ModVersion, error)
+	GetVersion(s *Settings, cfg *config.Config, modID, versionID string) (ModVersion, error)
+	GetLatestVersion(s *Settings, cfg *config.Config, modID, channel string) (ModVersion, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{}
+)
+
+// Register adds a provider to the registry, replacing any provider that was
+// previously registered under the same name.
+func Register(p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[p.Name()] = p
+}
+
+// Unregister removes the provider with the given name from the registry.
+func Unregister(name string) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	delete(providers, name)
+}
+
+// GetProvider returns the provider registered under the given platform name.
+func GetProvider(platform string) (Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[platform]
+	if !ok {
+		logger.Log.Printf("Unknown platform: %s", platform)
+		return nil, fmt.Errorf("unknown platform: %s", platform)
+	}
+	return p, nil
+}
+
+// Providers returns the names of all registered providers in sorted order.
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// HasProvider reports whether a provider is registered under the given name.
+func HasProvider(platform string) bool {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	_, ok := providers[platform]
+	return ok
+}
@@ -0,0 +1,181 @@
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// testSettings returns settings that send every platform request to the
+// server, without a cache or retries.
+func testSettings(srv *httptest.Server) *Settings {
+	return &Settings{
+		ModrinthURL:      srv.URL,
+		CurseForgeURL:    srv.URL,
+		CurseForgeAPIURL: srv.URL,
+		GitHubAPIURL:     srv.URL,
+		MavenRepos:       []string{srv.URL},
+		Client:           srv.Client(),
+		MojangMetaURL:    srv.URL,
+		FabricMetaURL:    srv.URL,
+		QuiltMetaURL:     srv.URL,
+		ForgeMavenURL:    srv.URL,
+		NeoForgeMavenURL: srv.URL,
+	}
+}
+
+func testConfig(minecraft, loader string) *config.Config {
+	return &config.Config{
+		Name:      "test",
+		Minecraft: minecraft,
+		Loader:    loader,
+		Channel:   config.ChannelRelease,
+		Mods:      map[string]config.Mod{},
+	}
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Errorf("encoding response: %v", err)
+	}
+}
+
+// fakeProvider serves a fixed list of versions, newest first.
+type fakeProvider struct {
+	name     string
+	versions []ModVersion
+}
+
+func (p fakeProvider) Name() string { return p.name }
+
+func (p fakeProvider) SearchMods(s *Settings, cfg *config.Config, query string, opts SearchOptions) (*SearchResult, error) {
+	return &SearchResult{
+		Hits:     []ModSearch{{ID: query, Name: query, Versions: p.versions}},
+		Page:     opts.Page,
+		PageSize: opts.PageSize,
+	}, nil
+}
+
+func (p fakeProvider) GetModVersions(s *Settings, cfg *config.Config, modID string) ([]ModVersion, error) {
+	return p.versions, nil
+}
+
+func (p fakeProvider) GetVersion(s *Settings, cfg *config.Config, modID, versionID string) (ModVersion, error) {
+	for _, v := range p.versions {
+		if v.ID == versionID {
+			return v, nil
+		}
+	}
+	return ModVersion{}, errors.New("version not found")
+}
+
+func (p fakeProvider) GetLatestVersion(s *Settings, cfg *config.Config, modID, channel string) (ModVersion, error) {
+	return latestVersion(cfg, p.versions, channel)
+}
+
+func TestRegistry(t *testing.T) {
+	p := fakeProvider{
+		name: "fake",
+		versions: []ModVersion{
+			{ID: "3", Version: "3.0-beta", Channel: ChannelBeta, Loaders: []string{"fabric"}},
+			{ID: "2", Version: "2.0", Channel: ChannelRelease, Loaders: []string{"fabric"}},
+			{ID: "1", Version: "1.0", Channel: ChannelRelease, Loaders: []string{"fabric"}},
+		},
+	}
+	if HasProvider(p.name) {
+		t.Fatalf("provider %q registered before Register", p.name)
+	}
+	Register(p)
+	t.Cleanup(func() { Unregister(p.name) })
+
+	if !HasProvider(p.name) {
+		t.Errorf("HasProvider(%q) = false after Register", p.name)
+	}
+	names := Providers()
+	if !slices.Contains(names, p.name) {
+		t.Errorf("Providers() = %v, missing %q", names, p.name)
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("Providers() = %v, want sorted names", names)
+	}
+	for _, builtin := range []string{"curseforge", "github", "maven", "modrinth"} {
+		if !slices.Contains(names, builtin) {
+			t.Errorf("Providers() = %v, missing built-in %q", names, builtin)
+		}
+	}
+
+	got, err := GetProvider(p.name)
+	if err != nil {
+		t.Fatalf("GetProvider(%q) error = %v", p.name, err)
+	}
+	if got.Name() != p.name {
+		t.Errorf("GetProvider(%q).Name() = %q", p.name, got.Name())
+	}
+
+	cfg := testConfig("1.20.1", "fabric")
+	s := DefaultSettings()
+	latest, err := GetLatestVersion(s, cfg, "mod", p.name, ChannelRelease)
+	if err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+	if latest.ID != "2" {
+		t.Errorf("latest release = %s, want 2", latest.ID)
+	}
+	latest, err = GetLatestVersion(s, cfg, "mod", p.name, ChannelBeta)
+	if err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+	if latest.ID != "3" {
+		t.Errorf("latest beta = %s, want 3", latest.ID)
+	}
+
+	result, err := SearchMods(s, cfg, "query", p.name, SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchMods() error = %v", err)
+	}
+	if result.Page != 1 || result.PageSize != DefaultPageSize {
+		t.Errorf("SearchMods() page = %d, size = %d, want normalized options", result.Page, result.PageSize)
+	}
+
+	Unregister(p.name)
+	if HasProvider(p.name) {
+		t.Errorf("HasProvider(%q) = true after Unregister", p.name)
+	}
+	if _, err := GetProvider(p.name); err == nil {
+		t.Errorf("GetProvider(%q) succeeded after Unregister", p.name)
+	}
+	if _, err := GetLatestVersion(s, cfg, "mod", p.name, ChannelRelease); err == nil {
+		t.Errorf("GetLatestVersion() succeeded for an unregistered platform")
+	}
+}
+
+func TestRegisterReplaces(t *testing.T) {
+	Register(fakeProvider{name: "fake", versions: []ModVersion{{ID: "old", Channel: ChannelRelease}}})
+	Register(fakeProvider{name: "fake", versions: []ModVersion{{ID: "new", Channel: ChannelRelease}}})
+	t.Cleanup(func() { Unregister("fake") })
+
+	cfg := testConfig("1.20.1", "fabric")
+	versions, err := GetModVersions(DefaultSettings(), cfg, "mod", "fake")
+	if err != nil {
+		t.Fatalf("GetModVersions() error = %v", err)
+	}
+	if len(versions) != 1 || versions[0].ID != "new" {
+		t.Errorf("GetModVersions() = %v, want the replacing provider's versions", versions)
+	}
+}
@@ -0,0 +1,253 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+const (
+	DefaultMaxRetries = 3
+
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+	// maxRateLimitWait is the longest a request waits for a rate limit to
+	// reset before giving up with a RateLimitError.
+	maxRateLimitWait = time.Minute
+)
+
+// StatusError is returned when a platform answers with an unexpected status.
+type StatusError struct {
+	Platform   string
+	StatusCode int
+	Attempts   int
+}
+
+func (e *StatusError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%s returned status %d after %d attempts", e.Platform, e.StatusCode, e.Attempts)
+	}
+	return fmt.Sprintf("%s returned status %d", e.Platform, e.StatusCode)
+}
+
+// RateLimitError is returned when a platform keeps rate limiting requests.
+// RetryAfter is how long the platform asked to wait, when it said so.
+type RateLimitError struct {
+	Platform   string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s rate limit reached, try again in %s", e.Platform, e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("%s rate limit reached", e.Platform)
+}
+
+// BlockedError is returned when a platform refuses a request, such as
+// CurseForge blocking the scraper or rejecting an API key.
+type BlockedError struct {
+	Platform string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%s refused the request (status 403)", e.Platform)
+}
+
+// RequestError is returned when a request keeps failing before a response
+// is received.
+type RequestError struct {
+	URL      string
+	Attempts int
+	Err      error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("request to %s failed after %d attempts: %v", e.URL, e.Attempts, e.Err)
+}
+
+func (e *RequestError) Unwrap() error { return e.Err }
+
+// statusError converts an unexpected response status to a typed error.
+func statusError(platform string, resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		// GitHub answers 403 rather than 429 once the rate limit is spent.
+		resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-Ratelimit-Remaining") == "0":
+		return &RateLimitError{Platform: platform, RetryAfter: rateLimitWait(resp)}
+	case resp.StatusCode == http.StatusForbidden:
+		return &BlockedError{Platform: platform}
+	}
+	return &StatusError{Platform: platform, StatusCode: resp.StatusCode, Attempts: 1}
+}
+
+// rateLimits records until when each host's rate limit is exhausted, so
+// concurrent requests wait instead of running into 429 responses.
+var rateLimits = struct {
+	sync.Mutex
+	resets map[string]time.Time
+}{resets: map[string]time.Time{}}
+
+// waitForRateLimit blocks until the host's rate limit resets. It returns
+// false when the reset is too far away to wait for.
+func waitForRateLimit(host string) (time.Duration, bool) {
+	rateLimits.Lock()
+	reset, ok := rateLimits.resets[host]
+	rateLimits.Unlock()
+	if !ok {
+		return 0, true
+	}
+	wait := time.Until(reset)
+	if wait <= 0 {
+		return 0, true
+	}
+	if wait > maxRateLimitWait {
+		return wait, false
+	}
+	logger.Log.Printf("Rate limit of %s exhausted, waiting %s", host, wait.Round(time.Millisecond))
+	time.Sleep(wait)
+	return 0, true
+}
+
+// recordRateLimit remembers the reset time of a host whose rate limit is
+// exhausted. Modrinth sends the seconds until the reset, GitHub a Unix time.
+func recordRateLimit(host string, resp *http.Response) {
+	if resp.Header.Get("X-Ratelimit-Remaining") != "0" && resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	wait := rateLimitWait(resp)
+	if wait <= 0 {
+		return
+	}
+	rateLimits.Lock()
+	rateLimits.resets[host] = time.Now().Add(wait)
+	rateLimits.Unlock()
+}
+
+func rateLimitWait(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-Ratelimit-Reset"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	// Values this large are a Unix time rather than a number of seconds.
+	if reset > 1_000_000_000 {
+		return time.Until(time.Unix(reset, 0))
+	}
+	return time.Duration(reset) * time.Second
+}
+
+// backoff returns the jittered delay before the given retry.
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func transientError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// send performs a request, waiting for exhausted rate limits and retrying
+// timeouts, 429 and 5xx responses with jittered backoff. Responses with
+// other statuses are returned to the caller.
+func (s *Settings) send(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	maxRetries := s.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if wait, ok := waitForRateLimit(host); !ok {
+			logger.Log.Printf("Rate limit of %s resets in %s, giving up", host, wait.Round(time.Second))
+			return nil, &RateLimitError{Platform: host, RetryAfter: wait}
+		}
+
+		r := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+
+		resp, err := s.HTTPClient().Do(r)
+		if err != nil {
+			if !transientError(err) || attempt >= maxRetries {
+				if attempt == 0 {
+					return nil, err
+				}
+				return nil, &RequestError{URL: req.URL.String(), Attempts: attempt + 1, Err: err}
+			}
+			delay := backoff(attempt)
+			logger.Log.Printf("Request to %s failed: %v, retrying in %s", req.URL, err, delay.Round(time.Millisecond))
+			time.Sleep(delay)
+			continue
+		}
+
+		recordRateLimit(host, resp)
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if attempt >= maxRetries {
+			logger.Log.Printf("Request to %s returned status %d, giving up after %d attempts", req.URL, resp.StatusCode, attempt+1)
+			if resp.StatusCode == http.StatusTooManyRequests {
+				return nil, &RateLimitError{Platform: host, RetryAfter: rateLimitWait(resp)}
+			}
+			return nil, &StatusError{Platform: host, StatusCode: resp.StatusCode, Attempts: attempt + 1}
+		}
+
+		// Rate limited requests wait for the reset recorded above instead.
+		delay := backoff(attempt)
+		if resp.StatusCode == http.StatusTooManyRequests && rateLimitWait(resp) > 0 {
+			delay = 0
+		}
+		logger.Log.Printf("Request to %s returned status %d, retrying in %s", req.URL, resp.StatusCode, delay.Round(time.Millisecond))
+		time.Sleep(delay)
+	}
+}
+
+// Fetch performs a GET request through the retrying, caching request layer
+// and passes the body of a successful response to decode. It is used by
+// packages that read other metadata services with the source settings.
+func (s *Settings) Fetch(platform, reqURL string, decode func(io.Reader) error) error {
+	req, err := s.newRequest("GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Printf("Making HTTP request to %s: %s", platform, reqURL)
+	resp, err := s.do(req)
+	if err != nil {
+		logger.Log.Printf("Error making request: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.Log.Printf("%s returned status %d", platform, resp.StatusCode)
+		return statusError(platform, resp)
+	}
+	return decode(resp.Body)
+}
@@ -0,0 +1,206 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryServerErrors(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+	s.MaxRetries = 1
+
+	start := time.Now()
+	body, err := fetchBody(s, srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if body != "ok" || requests.Load() != 2 {
+		t.Errorf("body = %q after %d requests, want ok after 2", body, requests.Load())
+	}
+	// The first retry waits between half and all of the base delay.
+	if elapsed := time.Since(start); elapsed < retryBaseDelay/2 {
+		t.Errorf("retried after %s, want at least %s", elapsed, retryBaseDelay/2)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+	s.MaxRetries = 1
+
+	_, err := fetchBody(s, srv.URL)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Fetch() error = %v, want StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.Attempts != 2 || requests.Load() != 2 {
+		t.Errorf("error = %+v after %d requests, want 503 after 2 attempts", statusErr, requests.Load())
+	}
+}
+
+func TestNoRetryOnClientErrors(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+	s.MaxRetries = 3
+
+	_, err := fetchBody(s, srv.URL)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Fetch() error = %v, want a 404 StatusError", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+	s.MaxRetries = 1
+
+	start := time.Now()
+	body, err := fetchBody(s, srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if body != "ok" || requests.Load() != 2 {
+		t.Errorf("body = %q after %d requests, want ok after 2", body, requests.Load())
+	}
+	// The retry waits for the reset the platform asked for.
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("retried after %s, want about 1s", elapsed)
+	}
+}
+
+func TestRateLimitTooLong(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+	s.MaxRetries = 3
+
+	_, err := fetchBody(s, srv.URL)
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("Fetch() error = %v, want RateLimitError", err)
+	}
+	if rateErr.RetryAfter < time.Minute {
+		t.Errorf("RetryAfter = %s, want about 2m", rateErr.RetryAfter)
+	}
+
+	// Later requests to the host give up without contacting it until the
+	// limit resets.
+	if _, err := fetchBody(s, srv.URL+"/other"); !errors.As(err, &rateErr) {
+		t.Errorf("Fetch() while rate limited error = %v, want RateLimitError", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
+
+func TestRateLimitRemainingHeader(t *testing.T) {
+	var requests atomic.Int32
+	var first, second time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			first = time.Now()
+			// Modrinth sends the seconds until its limit resets.
+			w.Header().Set("X-Ratelimit-Remaining", "0")
+			w.Header().Set("X-Ratelimit-Reset", "1")
+		} else {
+			second = time.Now()
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	s := testSettings(srv)
+
+	for range 2 {
+		if _, err := fetchBody(s, srv.URL); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if gap := second.Sub(first); gap < 900*time.Millisecond {
+		t.Errorf("second request sent %s after the limit was spent, want about 1s", gap)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{name: "retry after", header: http.Header{"Retry-After": {"30"}}, want: 30 * time.Second},
+		{name: "reset seconds", header: http.Header{"X-Ratelimit-Reset": {"15"}}, want: 15 * time.Second},
+		{name: "reset unix time", header: http.Header{"X-Ratelimit-Reset": {"4102444800"}}, want: time.Until(time.Unix(4102444800, 0))},
+		{name: "none", header: http.Header{}, want: 0},
+		{name: "invalid", header: http.Header{"Retry-After": {"soon"}}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rateLimitWait(&http.Response{Header: tt.header})
+			if diff := got - tt.want; diff < -time.Second || diff > time.Second {
+				t.Errorf("rateLimitWait() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		status int
+		header http.Header
+		want   string
+	}{
+		{status: http.StatusTooManyRequests, header: http.Header{}, want: "*sources.RateLimitError"},
+		// GitHub answers 403 once the rate limit is spent.
+		{status: http.StatusForbidden, header: http.Header{"X-Ratelimit-Remaining": {"0"}}, want: "*sources.RateLimitError"},
+		{status: http.StatusForbidden, header: http.Header{}, want: "*sources.BlockedError"},
+		{status: http.StatusNotFound, header: http.Header{}, want: "*sources.StatusError"},
+	}
+	for _, tt := range tests {
+		err := statusError("test", &http.Response{StatusCode: tt.status, Header: tt.header})
+		if got := fmt.Sprintf("%T", err); got != tt.want {
+			t.Errorf("statusError(%d, %v) = %s, want %s", tt.status, tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package sources
+
+import (
+	"slices"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/util"
+)
+
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 50
+)
+
+// Sort orders supported by SearchOptions.Sort.
+const (
+	SortRelevance = "relevance"
+	SortDownloads = "downloads"
+	SortFollows   = "follows"
+	SortNewest    = "newest"
+	SortUpdated   = "updated"
+)
+
+// SearchOptions controls paging and filtering of a mod search. Categories
+// are Modrinth category names, such as "optimization", for every platform;
+// CurseForge maps them to its own categories. ClientSide and ServerSide
+// filter on "required", "optional" or "unsupported" where the platform
+// supports it.
+type SearchOptions struct {
+	Page       int
+	PageSize   int
+	Sort       string
+	Categories []string
+	ClientSide string
+	ServerSide string
+}
+
+// SearchResult is one page of search hits together with the total number of
+// hits for the query.
+type SearchResult struct {
+	Hits      []ModSearch
+	TotalHits int
+	Page      int
+	PageSize  int
+}
+
+func (o SearchOptions) normalized() SearchOptions {
+	if o.Page < 1 {
+		o.Page = 1
+	}
+	if o.PageSize < 1 {
+		o.PageSize = DefaultPageSize
+	}
+	if o.PageSize > MaxPageSize {
+		o.PageSize = MaxPageSize
+	}
+	if !slices.Contains([]string{SortRelevance, SortDownloads, SortFollows, SortNewest, SortUpdated}, o.Sort) {
+		o.Sort = SortRelevance
+	}
+	return o
+}
+
+func (o SearchOptions) offset() int {
+	return (o.Page - 1) * o.PageSize
+}
+
+// fillVersions looks up the versions of every hit in parallel, keeping the
+// order of the hits as returned by the platform.
+func fillVersions(mods []ModSearch, versions func(id string) ([]ModVersion, error)) error {
+	type job struct {
+		index int
+		id    string
+	}
+
+	processMod := func(j job) error {
+		v, err := versions(j.id)
+		if err != nil {
+			logger.Log.Printf("Error getting versions for mod %s: %v", j.id, err)
+			return err
+		}
+		mods[j.index].Versions = v
+		return nil
+	}
+
+	jobs := make(chan job, len(mods))
+	results := util.WorkerPool(jobs, processMod, len(mods))
+
+	go func() {
+		for i, mod := range mods {
+			jobs <- job{index: i, id: mod.ID}
+		}
+		close(jobs)
+	}()
+
+	var firstErr error
+	for err := range results {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
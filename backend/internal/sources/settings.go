@@ -0,0 +1,300 @@
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+const (
+	DefaultModrinthURL      = "https://api.modrinth.com/v2"
+	DefaultCurseForgeURL    = "https://www.curseforge.com"
+	DefaultCurseForgeAPIURL = "https://api.curseforge.com"
+	DefaultGitHubAPIURL     = "https://api.github.com"
+	DefaultMavenRepository  = "https://repo1.maven.org/maven2"
+	DefaultMojangMetaURL    = "https://piston-meta.mojang.com"
+	DefaultFabricMetaURL    = "https://meta.fabricmc.net/v2"
+	DefaultQuiltMetaURL     = "https://meta.quiltmc.org/v3"
+	DefaultForgeMavenURL    = "https://maven.minecraftforge.net"
+	DefaultNeoForgeMavenURL = "https://maven.neoforged.net/releases"
+	DefaultUserAgent        = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36"
+	DefaultTimeout          = 30 * time.Second
+)
+
+// Settings configures how sources reach their platforms. Every source call
+// receives the settings explicitly, which allows pointing Packsmith at a
+// caching mirror or at an httptest server. GET responses are cached on disk
+// when Cache is set.
+type Settings struct {
+	ModrinthURL      string
+	CurseForgeURL    string
+	CurseForgeAPIURL string
+	CurseForgeAPIKey string
+	GitHubAPIURL     string
+	GitHubToken      string
+	MavenRepos       []string
+	UserAgent        string
+	Client           *http.Client
+	Cache            *HTTPCache
+	MaxRetries       int
+
+	// Hosts of the Minecraft and mod loader version catalogs.
+	MojangMetaURL    string
+	FabricMetaURL    string
+	QuiltMetaURL     string
+	ForgeMavenURL    string
+	NeoForgeMavenURL string
+
+	// refresh makes cached responses be revalidated regardless of their age.
+	refresh bool
+	// stale makes expired cached responses be served when the platform
+	// cannot be reached.
+	stale bool
+}
+
+// Options are the user-facing source settings. Empty fields fall back to
+// the defaults for the public platform hosts.
+type Options struct {
+	ModrinthURL      string
+	CurseForgeURL    string
+	CurseForgeAPIURL string
+	CurseForgeAPIKey string
+	GitHubAPIURL     string
+	GitHubToken      string
+	MavenRepos       []string
+	UserAgent        string
+	Proxy            string
+	TimeoutSeconds   int
+	CacheDir         string
+	CacheTTLSeconds  int
+	DisableCache     bool
+	MaxRetries       *int
+	MojangMetaURL    string
+	FabricMetaURL    string
+	QuiltMetaURL     string
+	ForgeMavenURL    string
+	NeoForgeMavenURL string
+}
+
+// DefaultSettings returns settings for the public platform hosts with a
+// client that honors the proxy environment variables.
+func DefaultSettings() *Settings {
+	s, _ := NewSettings(Options{})
+	return s
+}
+
+// NewSettings builds settings and a shared HTTP client from the options.
+func NewSettings(opts Options) (*Settings, error) {
+	logger.Log.Println("Creating source settings")
+	timeout := DefaultTimeout
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	client, err := NewHTTPClient(timeout, opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRetries := DefaultMaxRetries
+	if opts.MaxRetries != nil {
+		maxRetries = *opts.MaxRetries
+	}
+
+	var cache *HTTPCache
+	if !opts.DisableCache {
+		cache, err = newCache(opts)
+		if err != nil {
+			logger.Log.Printf("Continuing without HTTP cache: %v", err)
+		}
+	}
+
+	return &Settings{
+		ModrinthURL:      strings.TrimRight(orDefault(opts.ModrinthURL, DefaultModrinthURL), "/"),
+		CurseForgeURL:    strings.TrimRight(orDefault(opts.CurseForgeURL, DefaultCurseForgeURL), "/"),
+		CurseForgeAPIURL: strings.TrimRight(orDefault(opts.CurseForgeAPIURL, DefaultCurseForgeAPIURL), "/"),
+		CurseForgeAPIKey: strings.TrimSpace(opts.CurseForgeAPIKey),
+		GitHubAPIURL:     strings.TrimRight(orDefault(opts.GitHubAPIURL, DefaultGitHubAPIURL), "/"),
+		GitHubToken:      strings.TrimSpace(opts.GitHubToken),
+		MavenRepos:       mavenRepos(opts.MavenRepos),
+		UserAgent:        orDefault(opts.UserAgent, DefaultUserAgent),
+		Client:           client,
+		Cache:            cache,
+		MaxRetries:       maxRetries,
+		MojangMetaURL:    strings.TrimRight(orDefault(opts.MojangMetaURL, DefaultMojangMetaURL), "/"),
+		FabricMetaURL:    strings.TrimRight(orDefault(opts.FabricMetaURL, DefaultFabricMetaURL), "/"),
+		QuiltMetaURL:     strings.TrimRight(orDefault(opts.QuiltMetaURL, DefaultQuiltMetaURL), "/"),
+		ForgeMavenURL:    strings.TrimRight(orDefault(opts.ForgeMavenURL, DefaultForgeMavenURL), "/"),
+		NeoForgeMavenURL: strings.TrimRight(orDefault(opts.NeoForgeMavenURL, DefaultNeoForgeMavenURL), "/"),
+	}, nil
+}
+
+func newCache(opts Options) (*HTTPCache, error) {
+	dir := opts.CacheDir
+	if dir == "" {
+		var err error
+		if dir, err = DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	ttl := DefaultCacheTTL
+	if opts.CacheTTLSeconds > 0 {
+		ttl = time.Duration(opts.CacheTTLSeconds) * time.Second
+	}
+	return NewHTTPCache(dir, ttl)
+}
+
+// DefaultOptionsFile returns where the source options are saved, inside the
+// user config directory.
+func DefaultOptionsFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "packsmith", "sources.json"), nil
+}
+
+// LoadOptions reads options saved by SaveOptions. A missing file gives the
+// zero options, which use the public platform hosts.
+func LoadOptions(file string) (Options, error) {
+	var opts Options
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return opts, nil
+	}
+	if err != nil {
+		logger.Log.Printf("Error reading source options: %v", err)
+		return opts, err
+	}
+	if err := json.Unmarshal(data, &opts); err != nil {
+		logger.Log.Printf("Error decoding source options: %v", err)
+		return opts, fmt.Errorf("reading %s: %w", file, err)
+	}
+	return opts, nil
+}
+
+// SaveOptions writes the options to file. The file is only readable by the
+// user, since it holds API keys.
+func SaveOptions(file string, opts Options) error {
+	data, err := json.MarshalIndent(opts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		logger.Log.Printf("Error creating config folder: %v", err)
+		return err
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		logger.Log.Printf("Error saving source options: %v", err)
+		return err
+	}
+	return nil
+}
+
+// mavenRepos trims the repository URLs, falling back to Maven Central when
+// none are given.
+func mavenRepos(repos []string) []string {
+	var trimmed []string
+	for _, repo := range repos {
+		if repo = strings.TrimRight(strings.TrimSpace(repo), "/"); repo != "" {
+			trimmed = append(trimmed, repo)
+		}
+	}
+	if len(trimmed) == 0 {
+		return []string{DefaultMavenRepository}
+	}
+	return trimmed
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// NewHTTPClient builds a client with the given timeout. An empty proxy uses
+// the HTTP_PROXY/HTTPS_PROXY environment variables.
+func NewHTTPClient(timeout time.Duration, proxy string) (*http.Client, error) {
+	logger.Log.Printf("Creating HTTP client with timeout: %s, proxy: %q", timeout, proxy)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			logger.Log.Printf("Error parsing proxy URL: %v", err)
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.ResponseHeaderTimeout = timeout
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// WithCurseForgeAPIKey returns a copy of the settings using the given key.
+// An empty key makes the CurseForge provider fall back to the scraper.
+func (s *Settings) WithCurseForgeAPIKey(apiKey string) *Settings {
+	c := *s
+	c.CurseForgeAPIKey = strings.TrimSpace(apiKey)
+	logger.Log.Printf("CurseForge API enabled: %t", c.CurseForgeAPIKey != "")
+	return &c
+}
+
+// WithForceRefresh returns a copy of the settings that revalidates every
+// cached response instead of trusting its TTL.
+func (s *Settings) WithForceRefresh() *Settings {
+	c := *s
+	c.refresh = true
+	return &c
+}
+
+// WithStaleCache returns a copy of the settings that serves expired cached
+// responses when a platform cannot be reached, for data that rarely changes
+// such as version catalogs.
+func (s *Settings) WithStaleCache() *Settings {
+	c := *s
+	c.stale = true
+	return &c
+}
+
+// HTTPClient returns the client used for every source request.
+func (s *Settings) HTTPClient() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+func (s *Settings) newRequest(method, reqURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, reqURL, body)
+	if err != nil {
+		logger.Log.Printf("Error creating request: %v", err)
+		return nil, err
+	}
+	s.setHeaders(req)
+	return req, nil
+}
+
+func (s *Settings) setHeaders(req *http.Request) {
+	logger.Log.Println("Setting headers for HTTP request")
+	userAgent := s.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+}
+
+func (s *Settings) curseforgeAPIEnabled() bool {
+	return s.CurseForgeAPIKey != ""
+}
@@ -1,19 +1,91 @@
 package sources
 
 import (
+	"errors"
 	"fmt"
-	"net/http"
+	"regexp"
 	"strings"
 
 	"github.com/sqot0/packsmith/backend/internal/config"
 	"github.com/sqot0/packsmith/backend/internal/logger"
 )
 
-func setHeadersForRequest(req *http.Request) {
-	logger.Log.Println("Setting headers for HTTP request")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+// Release channels of a ModVersion.
+const (
+	ChannelRelease = config.ChannelRelease
+	ChannelBeta    = config.ChannelBeta
+	ChannelAlpha   = config.ChannelAlpha
+)
+
+// ErrNoCompatibleVersion is returned when a mod has no version for the
+// project's Minecraft version and loader that the channel policy allows.
+var ErrNoCompatibleVersion = errors.New("no compatible version found")
+
+var channelRank = map[string]int{ChannelRelease: 0, ChannelBeta: 1, ChannelAlpha: 2}
+
+// ChannelAllows reports whether a version on the given channel may be used
+// under the given channel policy. Unknown channels are treated as alpha.
+func ChannelAllows(policy, channel string) bool {
+	rank, ok := channelRank[channel]
+	if !ok {
+		rank = channelRank[ChannelAlpha]
+	}
+	return rank <= channelRank[policy]
+}
+
+// latestVersion returns the first version allowed by the channel policy,
+// versions are expected newest first. Versions for the project loader are
+// preferred over versions for a fallback loader.
+func latestVersion(cfg *config.Config, versions []ModVersion, channel string) (ModVersion, error) {
+	if v, ok := firstAllowed(cfg, versions, channel); ok {
+		logger.Log.Printf("Latest %s version found: %s", channel, v.Version)
+		return v, nil
+	}
+	logger.Log.Printf("No compatible %s version found", channel)
+	return ModVersion{}, fmt.Errorf("%w on the %s channel", ErrNoCompatibleVersion, channel)
+}
+
+func firstAllowed(cfg *config.Config, versions []ModVersion, channel string) (ModVersion, bool) {
+	for i, loader := range acceptedLoaders(cfg) {
+		// Versions for the project loader have no fallback loader.
+		if i == 0 {
+			loader = ""
+		}
+		for _, v := range versions {
+			if ChannelAllows(channel, v.Channel) && fallbackLoader(cfg, v) == loader {
+				return v, true
+			}
+		}
+	}
+	return ModVersion{}, false
+}
+
+// ModVersion is a single downloadable version of a mod. ID is the
+// platform's immutable version or file ID and ProjectID the immutable ID of
+// the mod it belongs to.
+type ModVersion struct {
+	ID           string
+	ProjectID    string
+	Version      string
+	Channel      string
+	Published    string
+	FileName     string
+	FileSize     int64
+	GameVersions []string
+	Loaders      []string
+	Changelog    string
+	DownloadURL  string
+	// FallbackLoader is set when the version does not support the project
+	// loader and is used through a loader the project loader accepts.
+	FallbackLoader string
+}
+
+// DownloadName is the filename hint passed to fs.Download.
+func (v ModVersion) DownloadName() string {
+	if v.FileName != "" {
+		return v.FileName
+	}
+	return v.Version
 }
 
 type ModSearch struct {
@@ -24,71 +96,121 @@ type ModSearch struct {
 	ServerSide  string
 	Downloads   string
 	URL         string
-	Versions    []string
+	Versions    []ModVersion
 }
 
 type ModMetaData struct {
 	URL, Side, Version string
+	VersionID          string
+	IgnoreConflicts    bool
 }
 
-func SearchMods(cfg *config.Config, query, platform string) ([]ModSearch, error) {
+// projectRef returns the immutable project ID stored for a mod, falling back
+// to the key the mod is stored under for mods that are not in the project.
+func projectRef(cfg *config.Config, modID string) string {
+	if mod, ok := cfg.Mods[modID]; ok && mod.ProjectID != "" {
+		return mod.ProjectID
+	}
+	return modID
+}
+
+func SearchMods(s *Settings, cfg *config.Config, query, platform string, opts SearchOptions) (*SearchResult, error) {
 	logger.Log.Printf("Searching mods on platform: %s with query: %s", platform, query)
-	switch platform {
-	case "modrinth":
-		return searchModsModrinth(cfg, query)
-	case "curseforge":
-		return searchModsCurseforge(cfg, query)
-	default:
-		logger.Log.Printf("Unknown platform: %s", platform)
-		return nil, fmt.Errorf("unknown platform: %s", platform)
+	p, err := GetProvider(platform)
+	if err != nil {
+		return nil, err
+	}
+	result, err := p.SearchMods(s, cfg, query, opts.normalized())
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Hits {
+		withFallbackLoaders(cfg, result.Hits[i].Versions)
+	}
+	return result, nil
+}
+
+func GetVersion(s *Settings, cfg *config.Config, modID, platform, versionID string) (ModVersion, error) {
+	logger.Log.Printf("Getting version %s for mod: %s on platform: %s", versionID, modID, platform)
+	p, err := GetProvider(platform)
+	if err != nil {
+		return ModVersion{}, err
 	}
+	v, err := p.GetVersion(s, cfg, modID, versionID)
+	if err != nil {
+		return ModVersion{}, err
+	}
+	v.FallbackLoader = fallbackLoader(cfg, v)
+	return v, nil
 }
 
-func GetDownloadURL(cfg *config.Config, modID, platform, version string) (string, error) {
-	logger.Log.Printf("Getting download URL for mod: %s on platform: %s", modID, platform)
-	switch platform {
-	case "modrinth":
-		return getDownloadURLModrinth(cfg, modID, version)
-	case "curseforge":
-		return getDownloadURLCurseforge(cfg, modID, version)
-	default:
-		logger.Log.Printf("Unknown platform: %s", platform)
-		return "", fmt.Errorf("unknown platform: %s", platform)
+// FindVersion looks a version up by its ID, or by its version number when no
+// ID is known.
+func FindVersion(s *Settings, cfg *config.Config, modID, platform, versionID, version string) (ModVersion, error) {
+	if versionID != "" {
+		return GetVersion(s, cfg, modID, platform, versionID)
+	}
+
+	logger.Log.Printf("Finding version %s for mod: %s on platform: %s", version, modID, platform)
+	versions, err := GetModVersions(s, cfg, modID, platform)
+	if err != nil {
+		return ModVersion{}, err
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return v, nil
+		}
 	}
+	logger.Log.Printf("Could not find version: %s", version)
+	return ModVersion{}, fmt.Errorf("could not find version: %s", version)
 }
 
-func GetModVersions(cfg *config.Config, modID, platform string) ([]string, error) {
+func GetModVersions(s *Settings, cfg *config.Config, modID, platform string) ([]ModVersion, error) {
 	logger.Log.Printf("Getting versions for mod: %s on platform: %s", modID, platform)
-	switch platform {
-	case "modrinth":
-		return getModVersionsModrinth(cfg, modID)
-	case "curseforge":
-		return getModVersionsCurseforge(cfg, modID)
-	default:
-		logger.Log.Printf("Unknown platform: %s", platform)
-		return nil, fmt.Errorf("unknown platform: %s", platform)
+	p, err := GetProvider(platform)
+	if err != nil {
+		return nil, err
 	}
+	versions, err := p.GetModVersions(s, cfg, modID)
+	if err != nil {
+		return nil, err
+	}
+	return withFallbackLoaders(cfg, versions), nil
 }
 
-func GetLatestVersion(cfg *config.Config, modID, platform string) (string, error) {
-	logger.Log.Printf("Getting latest version for mod: %s on platform: %s", modID, platform)
-	switch platform {
-	case "modrinth":
-		return getLatestVersionModrinth(cfg, modID)
-	case "curseforge":
-		return getLatestVersionCurseforge(cfg, modID)
-	default:
-		logger.Log.Printf("Unknown platform: %s", platform)
-		return "", fmt.Errorf("unknown platform: %s", platform)
+func GetLatestVersion(s *Settings, cfg *config.Config, modID, platform, channel string) (ModVersion, error) {
+	logger.Log.Printf("Getting latest %s version for mod: %s on platform: %s", channel, modID, platform)
+	p, err := GetProvider(platform)
+	if err != nil {
+		return ModVersion{}, err
+	}
+	v, err := p.GetLatestVersion(s, cfg, modID, channel)
+	if err != nil {
+		return ModVersion{}, err
 	}
+	v.FallbackLoader = fallbackLoader(cfg, v)
+	return v, nil
 }
 
-func GetModPlatform(source string) string {
-	logger.Log.Printf("Determining platform for source: %s", source)
-	if strings.HasPrefix(source, "https://www.curseforge.com") {
-		logger.Log.Println("Platform: curseforge")
-		return "curseforge"
+// namedLoaders returns the loaders named in a file name or version.
+func namedLoaders(name string) []string {
+	name = strings.ToLower(name)
+	var loaders []string
+	for _, loader := range []string{"neoforge", "forge", "fabric", "quilt"} {
+		if !strings.Contains(name, loader) {
+			continue
+		}
+		// "neoforge" also contains "forge".
+		if loader == "forge" && strings.Count(name, "forge") == strings.Count(name, "neoforge") {
+			continue
+		}
+		loaders = append(loaders, loader)
 	}
-	logger.Log.Println("Platform: modrinth")
-	return "modrinth"
+	return loaders
+}
+
+// minecraftVersionPattern matches a Minecraft version that is not part of a
+// longer version, so 1.20 does not match 1.20.1.
+func minecraftVersionPattern(version string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^0-9.])` + regexp.QuoteMeta(version) + `($|[^0-9.]|\.[^0-9])`)
 }
@@ -0,0 +1,53 @@
+package sources
+
+import (
+	"github.com/sqot0/packsmith/backend/internal/config"
+	"github.com/sqot0/packsmith/backend/internal/logger"
+)
+
+// UpdateQuery identifies an installed mod file by its hashes, along with the
+// channel policy its update has to satisfy.
+type UpdateQuery struct {
+	Hashes  FileHashes
+	Channel string
+}
+
+// UpdateChecker is implemented by providers that can look up the latest
+// version of many installed files at once. The returned map is keyed like
+// queries, mods the platform could not answer for are left out.
+type UpdateChecker interface {
+	CheckUpdates(s *Settings, cfg *config.Config, queries map[string]UpdateQuery) (map[string]ModVersion, error)
+}
+
+// SupportsBatchUpdates reports whether the platform can check updates by
+// file hash.
+func SupportsBatchUpdates(platform string) bool {
+	p, err := GetProvider(platform)
+	if err != nil {
+		return false
+	}
+	_, ok := p.(UpdateChecker)
+	return ok
+}
+
+// CheckUpdates looks up the latest versions of the queried mods in batch.
+func CheckUpdates(s *Settings, cfg *config.Config, platform string, queries map[string]UpdateQuery) (map[string]ModVersion, error) {
+	logger.Log.Printf("Checking updates for %d mods on %s in batch", len(queries), platform)
+	p, err := GetProvider(platform)
+	if err != nil {
+		return nil, err
+	}
+	uc, ok := p.(UpdateChecker)
+	if !ok {
+		return nil, nil
+	}
+	latest, err := uc.CheckUpdates(s, cfg, queries)
+	if err != nil {
+		return nil, err
+	}
+	for modID, v := range latest {
+		v.FallbackLoader = fallbackLoader(cfg, v)
+		latest[modID] = v
+	}
+	return latest, nil
+}
@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,12 +15,17 @@ import (
 )
 
 type ModToUpdate struct {
-	ModId   string
-	Version string
-	URL     string
+	ModId     string
+	Version   string
+	VersionID string
+	FileName  string
+	URL       string
 }
 
-func CheckMods(cfg *config.Config, modIDs []string) ([]ModToUpdate, error) {
+// CheckMods finds the mods that have a newer version available. Mods on
+// platforms that support it are checked in batch by the hash of their cached
+// jar, the rest and any the batch could not answer for are checked one by one.
+func CheckMods(s *sources.Settings, cfg *config.Config, modIDs []string, projectPath string) ([]ModToUpdate, error) {
 	logger.Log.Printf("Checking updates for %d mods", len(modIDs))
 	type job struct {
 		modId string
@@ -28,53 +34,94 @@ func CheckMods(cfg *config.Config, modIDs []string) ([]ModToUpdate, error) {
 
 	type result struct {
 		modId   string
-		version string
-		url     string
+		version sources.ModVersion
 	}
 
-	processJob := func(j job) result {
-		logger.Log.Printf("Checking update for mod: %s", j.modId)
-		platform := sources.GetModPlatform(j.mod.Source)
+	var pending []job
+	batches := map[string]map[string]sources.UpdateQuery{}
+	for _, modID := range modIDs {
+		mod, ok := cfg.Mods[modID]
+		if !ok || mod.Platform == "" || mod.Locked {
+			logger.Log.Printf("Skipping mod %s (not found, no platform, or locked)", modID)
+			continue
+		}
+		if !sources.HasProvider(mod.Platform) {
+			logger.Log.Printf("Skipping mod %s (no provider registered for its platform)", modID)
+			continue
+		}
+		if sources.SupportsBatchUpdates(mod.Platform) && mod.Filename != "" {
+			// Older mods have no recorded hash, their jar is hashed instead.
+			hash := mod.SHA1
+			var err error
+			if hash == "" {
+				hash, err = fs.SHA1(filepath.Join(projectPath, "cache", mod.Filename))
+			}
+			if err == nil {
+				if batches[mod.Platform] == nil {
+					batches[mod.Platform] = map[string]sources.UpdateQuery{}
+				}
+				batches[mod.Platform][modID] = sources.UpdateQuery{
+					Hashes:  sources.FileHashes{SHA1: hash},
+					Channel: cfg.ModChannel(mod),
+				}
+				continue
+			}
+			logger.Log.Printf("Could not hash cached file of mod %s, checking it individually: %v", modID, err)
+		}
+		pending = append(pending, job{modId: modID, mod: mod})
+	}
 
-		version, err := sources.GetLatestVersion(cfg, j.modId, platform)
+	var results []result
+	for platform, queries := range batches {
+		latest, err := sources.CheckUpdates(s, cfg, platform, queries)
 		if err != nil {
-			logger.Log.Printf("Error checking mod %s: %v", j.modId, err)
-			return result{modId: j.modId, version: version, url: j.mod.URL}
+			logger.Log.Printf("Error checking updates on %s in batch: %v", platform, err)
 		}
-		logger.Log.Printf("Mod %s latest version: %s", j.modId, version)
+		for modID := range queries {
+			if version, ok := latest[modID]; ok {
+				results = append(results, result{modId: modID, version: version})
+			} else {
+				pending = append(pending, job{modId: modID, mod: cfg.Mods[modID]})
+			}
+		}
+	}
 
-		url, err := sources.GetDownloadURL(cfg, j.modId, platform, version)
-		return result{
-			modId:   j.modId,
-			version: version,
-			url:     url,
+	processJob := func(j job) result {
+		logger.Log.Printf("Checking update for mod: %s", j.modId)
+		version, err := sources.GetLatestVersion(s, cfg, j.modId, j.mod.Platform, cfg.ModChannel(j.mod))
+		if err != nil {
+			logger.Log.Printf("Error checking mod %s: %v", j.modId, err)
+			return result{modId: j.modId}
 		}
+		logger.Log.Printf("Mod %s latest version: %s (%s)", j.modId, version.Version, version.ID)
+		return result{modId: j.modId, version: version}
 	}
 
 	jobs := make(chan job)
-	results := util.WorkerPool(jobs, processJob, len(modIDs))
+	pool := util.WorkerPool(jobs, processJob, len(pending))
 
 	go func() {
-		for _, modID := range modIDs {
-			mod, ok := cfg.Mods[modID]
-			if !ok || mod.Source == "" || mod.Locked {
-				logger.Log.Printf("Skipping mod %s (not found, no source, or locked)", modID)
-				continue
-			}
-			jobs <- job{modId: modID, mod: mod}
+		for _, j := range pending {
+			jobs <- j
 		}
 		close(jobs)
 	}()
 
+	for r := range pool {
+		results = append(results, r)
+	}
+
 	modsToUpdate := make([]ModToUpdate, 0)
 
-	for r := range results {
-		if r.version != "" && r.version != cfg.Mods[r.modId].Version {
-			logger.Log.Printf("Mod %s needs update from %s to %s", r.modId, cfg.Mods[r.modId].Version, r.version)
+	for _, r := range results {
+		if isNewer(cfg.Mods[r.modId], r.version) {
+			logger.Log.Printf("Mod %s needs update from %s to %s", r.modId, cfg.Mods[r.modId].Version, r.version.Version)
 			modsToUpdate = append(modsToUpdate, ModToUpdate{
-				ModId:   r.modId,
-				Version: r.version,
-				URL:     r.url,
+				ModId:     r.modId,
+				Version:   r.version.Version,
+				VersionID: r.version.ID,
+				FileName:  r.version.FileName,
+				URL:       r.version.DownloadURL,
 			})
 		} else {
 			logger.Log.Printf("Mod %s is up to date", r.modId)
@@ -85,29 +132,63 @@ func CheckMods(cfg *config.Config, modIDs []string) ([]ModToUpdate, error) {
 	return modsToUpdate, nil
 }
 
-func UpdateMods(cfg *config.Config, mods []ModToUpdate, projectPath string) error {
+// isNewer reports whether latest differs from the installed version, by
+// version ID when the mod has one and by version number otherwise.
+func isNewer(mod config.Mod, latest sources.ModVersion) bool {
+	if latest.ID == "" && latest.Version == "" {
+		return false
+	}
+	if mod.VersionID != "" && latest.ID != "" {
+		return latest.ID != mod.VersionID
+	}
+	return latest.Version != mod.Version
+}
+
+// UpdateMods downloads the new versions and records them in cfg. Mods that
+// are no longer in cfg or have been locked are skipped. The jars of updated
+// mods are replaced as they finish, so cfg records every successful update
+// even when others fail, and the failures are returned joined. Saving cfg is
+// left to the caller.
+func UpdateMods(s *sources.Settings, cfg *config.Config, mods []ModToUpdate, projectPath string) error {
 	logger.Log.Printf("Updating %d mods", len(mods))
 	var mx sync.Mutex
 
 	processUpdate := func(mod ModToUpdate) error {
+		mx.Lock()
+		current, ok := cfg.Mods[mod.ModId]
+		mx.Unlock()
+		if !ok || current.Locked {
+			logger.Log.Printf("Skipping update of mod %s (not found or locked)", mod.ModId)
+			return nil
+		}
+
 		logger.Log.Printf("Updating mod: %s to version: %s", mod.ModId, mod.Version)
-		filename, err := fs.Download(projectPath, mod.URL, mod.Version)
+		name := mod.FileName
+		if name == "" {
+			name = mod.Version
+		}
+		file, err := fs.Download(s.HTTPClient(), projectPath, mod.URL, name)
 		if err != nil {
 			logger.Log.Printf("Error downloading mod %s: %v", mod.ModId, err)
 			return fmt.Errorf("%s: %w", mod.ModId, err)
 		}
 
-		modCfg := cfg.Mods[mod.ModId]
-
-		logger.Log.Printf("Removing old cache file for mod: %s", mod.ModId)
-		if err := os.RemoveAll(filepath.Join(projectPath, "cache", modCfg.Filename)); err != nil {
-			logger.Log.Printf("Error removing old cache file for %s: %v", mod.ModId, err)
-			return fmt.Errorf("%s: %w", mod.ModId, err)
+		// Releases often keep the asset name, the download then replaced
+		// the old jar in place.
+		if current.Filename != "" && current.Filename != file.Name {
+			logger.Log.Printf("Removing old cache file for mod: %s", mod.ModId)
+			if err := os.Remove(filepath.Join(projectPath, "cache", current.Filename)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logger.Log.Printf("Error removing old cache file for %s: %v", mod.ModId, err)
+				return fmt.Errorf("%s: %w", mod.ModId, err)
+			}
 		}
 
 		mx.Lock()
+		modCfg := cfg.Mods[mod.ModId]
 		modCfg.Version = mod.Version
-		modCfg.Filename = filename
+		modCfg.VersionID = mod.VersionID
+		modCfg.Filename = file.Name
+		modCfg.SHA1 = file.SHA1
 		modCfg.URL = mod.URL
 		cfg.Mods[mod.ModId] = modCfg
 		mx.Unlock()
@@ -125,18 +206,18 @@ func UpdateMods(cfg *config.Config, mods []ModToUpdate, projectPath string) erro
 		close(jobs)
 	}()
 
+	var errs []error
 	for err := range results {
 		if err != nil {
 			logger.Log.Printf("Error updating mod: %v", err)
+			errs = append(errs, err)
 		}
 	}
-
-	logger.Log.Println("Saving updated config")
-	err := config.Save(cfg)
-	if err != nil {
-		logger.Log.Printf("Error saving config: %v", err)
-		return err
+	if len(errs) > 0 {
+		logger.Log.Printf("Failed to update %d of %d mods", len(errs), len(mods))
+		return errors.Join(errs...)
 	}
-	logger.Log.Println("Config saved successfully")
+
+	logger.Log.Println("Mods updated")
 	return nil
 }
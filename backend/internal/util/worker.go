@@ -6,6 +6,16 @@ import (
 	"github.com/sqot0/packsmith/backend/internal/logger"
 )
 
+// MaxWorkers bounds the workers of pools whose jobs scale with the number of
+// mods in a project, so large projects do not open hundreds of connections
+// or files at once.
+const MaxWorkers = 8
+
+// Workers returns the number of workers for n jobs, at most MaxWorkers.
+func Workers(n int) int {
+	return min(n, MaxWorkers)
+}
+
 func WorkerPool[T any, R any](jobs <-chan T, fn func(T) R, numWorkers int) <-chan R {
 	logger.Log.Printf("Starting worker pool with %d workers", numWorkers)
 	out := make(chan R)
@@ -0,0 +1,140 @@
+package versions
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sqot0/packsmith/backend/internal/sources"
+)
+
+// Paths of the loader metadata on their Maven repositories.
+const (
+	forgeMetadataPath = "/net/minecraftforge/forge/maven-metadata.xml"
+	// NeoForge for 1.20.1 was published as a fork of Forge before the
+	// project got its own versioning.
+	neoforgeMetadataPath       = "/net/neoforged/neoforge/maven-metadata.xml"
+	neoforgeLegacyMetadataPath = "/net/neoforged/forge/maven-metadata.xml"
+)
+
+// metaLoaderVersions reads the loader list of a Fabric style meta service.
+// Quilt does not flag stable versions, there prereleases are recognised by
+// their version.
+func metaLoaderVersions(s *sources.Settings, platform, baseURL, minecraft string) ([]LoaderVersion, error) {
+	var data []struct {
+		Loader struct {
+			Version string `json:"version"`
+			Stable  *bool  `json:"stable"`
+		} `json:"loader"`
+	}
+	reqURL := fmt.Sprintf("%s/versions/loader/%s", baseURL, url.PathEscape(minecraft))
+	err := s.Fetch(platform, reqURL, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]LoaderVersion, 0, len(data))
+	for _, d := range data {
+		stable := !prerelease(d.Loader.Version)
+		if d.Loader.Stable != nil {
+			stable = *d.Loader.Stable
+		}
+		versions = append(versions, LoaderVersion{Version: d.Loader.Version, Minecraft: minecraft, Stable: stable})
+	}
+	return versions, nil
+}
+
+func fabricVersions(s *sources.Settings, minecraft string) ([]LoaderVersion, error) {
+	return metaLoaderVersions(s, "fabric meta", s.FabricMetaURL, minecraft)
+}
+
+func quiltVersions(s *sources.Settings, minecraft string) ([]LoaderVersion, error) {
+	return metaLoaderVersions(s, "quilt meta", s.QuiltMetaURL, minecraft)
+}
+
+func fetchMavenVersions(s *sources.Settings, platform, metadataURL string) ([]string, error) {
+	var metadata sources.MavenMetadata
+	err := s.Fetch(platform, metadataURL, func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(&metadata)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metadata.Versioning.Versions, nil
+}
+
+// prefixedVersions returns the versions published as "<minecraft>-<version>".
+func prefixedVersions(all []string, minecraft string) []LoaderVersion {
+	var versions []LoaderVersion
+	for _, v := range all {
+		version, ok := strings.CutPrefix(v, minecraft+"-")
+		if !ok {
+			continue
+		}
+		versions = append(versions, LoaderVersion{Version: version, Minecraft: minecraft, Stable: !prerelease(version)})
+	}
+	return versions
+}
+
+func forgeVersions(s *sources.Settings, minecraft string) ([]LoaderVersion, error) {
+	all, err := fetchMavenVersions(s, "forge maven", s.ForgeMavenURL+forgeMetadataPath)
+	if err != nil {
+		return nil, err
+	}
+	return prefixedVersions(all, minecraft), nil
+}
+
+func neoforgeVersions(s *sources.Settings, minecraft string) ([]LoaderVersion, error) {
+	if minecraft == "1.20.1" {
+		all, err := fetchMavenVersions(s, "neoforge maven", s.NeoForgeMavenURL+neoforgeLegacyMetadataPath)
+		if err != nil {
+			return nil, err
+		}
+		return prefixedVersions(all, minecraft), nil
+	}
+
+	all, err := fetchMavenVersions(s, "neoforge maven", s.NeoForgeMavenURL+neoforgeMetadataPath)
+	if err != nil {
+		return nil, err
+	}
+	var versions []LoaderVersion
+	for _, v := range all {
+		if neoforgeMinecraftVersion(v) == minecraft {
+			versions = append(versions, LoaderVersion{Version: v, Minecraft: minecraft, Stable: !prerelease(v)})
+		}
+	}
+	return versions, nil
+}
+
+// neoforgeMinecraftVersion derives the Minecraft version from a NeoForge
+// version, whose first two parts are the minor and patch version of
+// Minecraft: 21.1.72 is for 1.21.1 and 21.0.10 for 1.21.
+func neoforgeMinecraftVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	minor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return ""
+	}
+	patch, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return ""
+	}
+	if patch == 0 {
+		return fmt.Sprintf("1.%d", minor)
+	}
+	return fmt.Sprintf("1.%d.%d", minor, patch)
+}
+
+func prerelease(version string) bool {
+	v := strings.ToLower(version)
+	return strings.Contains(v, "beta") || strings.Contains(v, "alpha") || strings.Contains(v, "pre") || strings.Contains(v, "-rc")
+}
@@ -0,0 +1,208 @@
+// Package versions lists the Minecraft releases and mod loader versions a
+// project can be created for. Lists are fetched through the source request
+// layer, so they share its on-disk cache and retries.
+package versions
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/sources"
+)
+
+const minecraftManifestPath = "/mc/game/version_manifest_v2.json"
+
+// Types of MinecraftVersion.
+const (
+	TypeRelease  = "release"
+	TypeSnapshot = "snapshot"
+)
+
+// Loaders are the mod loaders a project can use.
+var Loaders = []string{"forge", "fabric", "neoforge", "quilt"}
+
+type MinecraftVersion struct {
+	ID       string
+	Type     string
+	Released string
+}
+
+// LoaderVersion is a version of a mod loader for one Minecraft version.
+// Stable is false for betas and other prereleases.
+type LoaderVersion struct {
+	Version   string
+	Minecraft string
+	Stable    bool
+}
+
+// ListMinecraftVersions returns the Minecraft versions newest first, only
+// releases unless snapshots are included. Old alpha and beta versions are
+// never listed.
+func ListMinecraftVersions(s *sources.Settings, includeSnapshots bool) ([]MinecraftVersion, error) {
+	logger.Log.Printf("Listing Minecraft versions, snapshots: %t", includeSnapshots)
+	s = s.WithStaleCache()
+	var manifest struct {
+		Versions []struct {
+			ID          string `json:"id"`
+			Type        string `json:"type"`
+			ReleaseTime string `json:"releaseTime"`
+		} `json:"versions"`
+	}
+	err := s.Fetch("minecraft version manifest", s.MojangMetaURL+minecraftManifestPath, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&manifest)
+	})
+	if err != nil {
+		logger.Log.Printf("Error fetching Minecraft versions: %v", err)
+		return nil, err
+	}
+
+	var versions []MinecraftVersion
+	for _, v := range manifest.Versions {
+		if v.Type == TypeRelease || (includeSnapshots && v.Type == TypeSnapshot) {
+			versions = append(versions, MinecraftVersion{ID: v.ID, Type: v.Type, Released: v.ReleaseTime})
+		}
+	}
+	logger.Log.Printf("Found %d Minecraft versions", len(versions))
+	return versions, nil
+}
+
+// ListLoaderVersions returns the versions of a loader available for a
+// Minecraft version, newest first.
+func ListLoaderVersions(s *sources.Settings, loader, minecraft string) ([]LoaderVersion, error) {
+	logger.Log.Printf("Listing %s versions for Minecraft %s", loader, minecraft)
+	s = s.WithStaleCache()
+	var versions []LoaderVersion
+	var err error
+	switch loader {
+	case "fabric":
+		versions, err = fabricVersions(s, minecraft)
+	case "quilt":
+		versions, err = quiltVersions(s, minecraft)
+	case "forge":
+		versions, err = forgeVersions(s, minecraft)
+	case "neoforge":
+		versions, err = neoforgeVersions(s, minecraft)
+	default:
+		logger.Log.Printf("Unknown loader: %s", loader)
+		return nil, fmt.Errorf("unknown loader: %s", loader)
+	}
+	if err != nil {
+		logger.Log.Printf("Error fetching %s versions: %v", loader, err)
+		return nil, err
+	}
+
+	slices.SortStableFunc(versions, func(a, b LoaderVersion) int {
+		return compareVersions(b.Version, a.Version)
+	})
+	logger.Log.Printf("Found %d %s versions for Minecraft %s", len(versions), loader, minecraft)
+	return versions, nil
+}
+
+// Resolve checks a Minecraft version and loader version against the
+// catalogs and returns the loader version to record. An empty loader
+// version resolves to the newest stable one. Catalogs that were fetched
+// before are used when offline, when they cannot be fetched at all the
+// versions are accepted unchecked and an empty loader version stays empty.
+func Resolve(s *sources.Settings, minecraft, loader, loaderVersion string) (string, error) {
+	logger.Log.Printf("Resolving %s %q for Minecraft %s", loader, loaderVersion, minecraft)
+	if !slices.Contains(Loaders, loader) {
+		logger.Log.Printf("Invalid loader specified: %s", loader)
+		return "", fmt.Errorf("loader must be either 'forge', 'neoforge', 'quilt' or 'fabric'")
+	}
+
+	resolved, err := resolve(s, minecraft, loader, loaderVersion)
+	if unreachable(err) {
+		logger.Log.Printf("Warning: version catalogs unreachable, not checking %s %q for Minecraft %s: %v", loader, loaderVersion, minecraft, err)
+		return loaderVersion, nil
+	}
+	return resolved, err
+}
+
+// unreachable reports whether err means a catalog could not be reached or
+// is down, as opposed to an answer from it. Rate limits and server errors
+// say nothing about the versions, so they count as unreachable too.
+func unreachable(err error) bool {
+	var (
+		urlErr    *url.Error
+		reqErr    *sources.RequestError
+		rateErr   *sources.RateLimitError
+		statusErr *sources.StatusError
+	)
+	switch {
+	case errors.As(err, &urlErr), errors.As(err, &reqErr), errors.As(err, &rateErr):
+		return true
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode >= 500
+	}
+	return false
+}
+
+func resolve(s *sources.Settings, minecraft, loader, loaderVersion string) (string, error) {
+	mcVersions, err := ListMinecraftVersions(s, true)
+	if err != nil {
+		return "", err
+	}
+	if !slices.ContainsFunc(mcVersions, func(v MinecraftVersion) bool { return v.ID == minecraft }) {
+		logger.Log.Printf("Unknown Minecraft version: %s", minecraft)
+		return "", fmt.Errorf("unknown minecraft version: %s", minecraft)
+	}
+
+	loaderVersions, err := ListLoaderVersions(s, loader, minecraft)
+	if err != nil {
+		return "", err
+	}
+	if len(loaderVersions) == 0 {
+		logger.Log.Printf("No %s versions for Minecraft %s", loader, minecraft)
+		return "", fmt.Errorf("%s is not available for minecraft %s", loader, minecraft)
+	}
+
+	if loaderVersion == "" {
+		for _, v := range loaderVersions {
+			if v.Stable {
+				return v.Version, nil
+			}
+		}
+		return loaderVersions[0].Version, nil
+	}
+	if !slices.ContainsFunc(loaderVersions, func(v LoaderVersion) bool { return v.Version == loaderVersion }) {
+		logger.Log.Printf("Unknown %s version %s for Minecraft %s", loader, loaderVersion, minecraft)
+		return "", fmt.Errorf("%s %s is not available for minecraft %s", loader, loaderVersion, minecraft)
+	}
+	return loaderVersion, nil
+}
+
+// compareVersions compares dotted versions numerically, part by part.
+// Parts that are not numbers, such as "beta", compare as text.
+func compareVersions(a, b string) int {
+	split := func(v string) []string {
+		return strings.FieldsFunc(v, func(r rune) bool { return r == '.' || r == '-' || r == '+' })
+	}
+	pa, pb := split(a), split(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = cmp.Compare(na, nb)
+		case errA == nil:
+			c = 1
+		case errB == nil:
+			c = -1
+		default:
+			c = strings.Compare(pa[i], pb[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(pa), len(pb))
+}
@@ -0,0 +1,235 @@
+package versions
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sqot0/packsmith/backend/internal/logger"
+	"github.com/sqot0/packsmith/backend/internal/sources"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+const forgeMetadata = `<metadata>
+  <groupId>net.minecraftforge</groupId>
+  <artifactId>forge</artifactId>
+  <versioning>
+    <versions>
+      <version>1.20.1-47.1.0</version>
+      <version>1.20.1-47.2.0</version>
+      <version>1.20.1-47.10.0</version>
+      <version>1.19.2-43.3.0</version>
+    </versions>
+  </versioning>
+</metadata>`
+
+const neoforgeMetadata = `<metadata>
+  <versioning>
+    <versions>
+      <version>21.0.10-beta</version>
+      <version>21.1.9</version>
+      <version>21.1.72</version>
+      <version>21.1.73-beta</version>
+    </versions>
+  </versioning>
+</metadata>`
+
+// newCatalogServer serves the Mojang manifest and the loader catalogs.
+func newCatalogServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+minecraftManifestPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"versions": []map[string]string{
+			{"id": "24w14a", "type": "snapshot", "releaseTime": "2024-04-03T12:00:00+00:00"},
+			{"id": "1.21.1", "type": "release", "releaseTime": "2024-08-08T12:00:00+00:00"},
+			{"id": "1.21", "type": "release", "releaseTime": "2024-06-13T12:00:00+00:00"},
+			{"id": "1.20.1", "type": "release", "releaseTime": "2023-06-12T12:00:00+00:00"},
+			{"id": "b1.7.3", "type": "old_beta", "releaseTime": "2011-07-07T12:00:00+00:00"},
+		}})
+	})
+	mux.HandleFunc("GET /versions/loader/{minecraft}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("minecraft") != "1.20.1" {
+			io.WriteString(w, "[]")
+			return
+		}
+		io.WriteString(w, `[
+			{"loader": {"version": "0.16.0-beta.1", "stable": false}},
+			{"loader": {"version": "0.15.11", "stable": true}},
+			{"loader": {"version": "0.15.7", "stable": true}}
+		]`)
+	})
+	mux.HandleFunc("GET "+forgeMetadataPath, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, forgeMetadata)
+	})
+	mux.HandleFunc("GET "+neoforgeMetadataPath, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, neoforgeMetadata)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func catalogSettings(srv *httptest.Server) *sources.Settings {
+	return &sources.Settings{
+		Client:           srv.Client(),
+		MojangMetaURL:    srv.URL,
+		FabricMetaURL:    srv.URL,
+		QuiltMetaURL:     srv.URL,
+		ForgeMavenURL:    srv.URL,
+		NeoForgeMavenURL: srv.URL,
+	}
+}
+
+func TestListMinecraftVersions(t *testing.T) {
+	s := catalogSettings(newCatalogServer(t))
+	tests := []struct {
+		snapshots bool
+		want      []string
+	}{
+		{snapshots: false, want: []string{"1.21.1", "1.21", "1.20.1"}},
+		{snapshots: true, want: []string{"24w14a", "1.21.1", "1.21", "1.20.1"}},
+	}
+	for _, tt := range tests {
+		versions, err := ListMinecraftVersions(s, tt.snapshots)
+		if err != nil {
+			t.Fatalf("ListMinecraftVersions(%t) error = %v", tt.snapshots, err)
+		}
+		var ids []string
+		for _, v := range versions {
+			ids = append(ids, v.ID)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("ListMinecraftVersions(%t) = %v, want %v", tt.snapshots, ids, tt.want)
+		}
+	}
+}
+
+func TestListLoaderVersions(t *testing.T) {
+	s := catalogSettings(newCatalogServer(t))
+	tests := []struct {
+		loader, minecraft string
+		want              []string
+	}{
+		{loader: "fabric", minecraft: "1.20.1", want: []string{"0.16.0-beta.1", "0.15.11", "0.15.7"}},
+		{loader: "quilt", minecraft: "1.21.1", want: nil},
+		// Maven lists are sorted numerically, not as text.
+		{loader: "forge", minecraft: "1.20.1", want: []string{"47.10.0", "47.2.0", "47.1.0"}},
+		{loader: "neoforge", minecraft: "1.21.1", want: []string{"21.1.73-beta", "21.1.72", "21.1.9"}},
+		{loader: "neoforge", minecraft: "1.21", want: []string{"21.0.10-beta"}},
+	}
+	for _, tt := range tests {
+		versions, err := ListLoaderVersions(s, tt.loader, tt.minecraft)
+		if err != nil {
+			t.Fatalf("ListLoaderVersions(%s, %s) error = %v", tt.loader, tt.minecraft, err)
+		}
+		var got []string
+		for _, v := range versions {
+			got = append(got, v.Version)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ListLoaderVersions(%s, %s) = %v, want %v", tt.loader, tt.minecraft, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s := catalogSettings(newCatalogServer(t))
+	tests := []struct {
+		name                             string
+		minecraft, loader, loaderVersion string
+		want                             string
+		wantErr                          bool
+	}{
+		{name: "newest stable", minecraft: "1.20.1", loader: "fabric", want: "0.15.11"},
+		{name: "known version", minecraft: "1.20.1", loader: "forge", loaderVersion: "47.2.0", want: "47.2.0"},
+		{name: "prerelease only", minecraft: "1.21", loader: "neoforge", want: "21.0.10-beta"},
+		{name: "unknown minecraft", minecraft: "1.99", loader: "fabric", wantErr: true},
+		{name: "unknown loader version", minecraft: "1.20.1", loader: "forge", loaderVersion: "1.0", wantErr: true},
+		{name: "loader not available", minecraft: "1.21.1", loader: "quilt", wantErr: true},
+		{name: "invalid loader", minecraft: "1.20.1", loader: "rift", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(s, tt.minecraft, tt.loader, tt.loaderVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveUnreachable(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr bool
+	}{
+		{name: "server error", handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}},
+		{name: "rate limited", handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}},
+		{name: "not found", handler: http.NotFound, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			t.Cleanup(srv.Close)
+
+			got, err := Resolve(catalogSettings(srv), "1.20.1", "fabric", "0.15.11")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != "0.15.11" {
+				t.Errorf("Resolve() = %q, want the version accepted unchecked", got)
+			}
+		})
+	}
+
+	// A closed connection leaves the request without any response.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	s := catalogSettings(srv)
+	srv.Close()
+	if got, err := Resolve(s, "1.20.1", "fabric", ""); err != nil || got != "" {
+		t.Errorf("Resolve() offline = %q, %v, want an empty version accepted", got, err)
+	}
+}
+
+func TestResolveOfflineUsesCache(t *testing.T) {
+	srv := newCatalogServer(t)
+	s := catalogSettings(srv)
+	cache, err := sources.NewHTTPCache(t.TempDir(), time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Cache = cache
+
+	if _, err := Resolve(s, "1.20.1", "fabric", ""); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	srv.Close()
+
+	// The expired catalogs are still checked against once offline.
+	got, err := Resolve(s, "1.20.1", "fabric", "")
+	if err != nil || got != "0.15.11" {
+		t.Errorf("Resolve() offline = %q, %v, want 0.15.11 from the cached catalogs", got, err)
+	}
+	if _, err := Resolve(s, "1.20.1", "fabric", "9.9.9"); err == nil {
+		t.Error("Resolve() offline accepted a version missing from the cached catalog")
+	}
+}
@@ -1,4 +1,5 @@
 // Service re-exports for cleaner imports
 export * as projectService from './project-service';
 export * as modService from './mod-service';
+export * as settingsService from './settings-service';
 export type { ModPlatform } from './mod-service';
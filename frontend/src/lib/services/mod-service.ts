@@ -8,31 +8,91 @@ import {
     ChangeModSide,
     ChangeModLocked,
     ChangeModVersion, GetModVersions,
+    CheckCompatibility,
+    ChangeModChannel,
+    SelectModsFolder,
+    ImportModsFolder,
+    SelectModFile,
+    AddLocalMod,
+    AddURLMod,
+    GetModDetails,
 } from '$backend';
-import type {ModSearchResult, ModSide, AddModOptions, ModUpdateInfo} from '$lib/types/mod';
+import type {SearchOptions, SearchPage, ModSide, AddModOptions, AddModResult, ModDependency, ModConflict, ModUpdateInfo, ModVersion, ReleaseChannel, ImportReport, ModDetails} from '$lib/types/mod';
 
-export type ModPlatform = 'modrinth' | 'curseforge';
+export type ModPlatform = 'modrinth' | 'curseforge' | 'github' | 'maven';
 
 /**
- * Searches for mods on the specified platform
+ * Searches for mods on the specified platform, one page at a time
  */
 export async function searchMods(
   query: string,
-  platform: ModPlatform
-): Promise<ModSearchResult[]> {
-  const results = await SearchMods(query, platform);
-  return results ?? [];
+  platform: ModPlatform,
+  options: SearchOptions
+): Promise<SearchPage> {
+  const page = await SearchMods(query, platform, options);
+  return { ...page, Hits: page.Hits ?? [] };
 }
 
 /**
- * Adds a mod to the current project
+ * Tells whether the platform can describe its mods with getModDetails
+ */
+export function hasModDetails(platform?: string): platform is 'modrinth' | 'curseforge' {
+  return platform === 'modrinth' || platform === 'curseforge';
+}
+
+/**
+ * Gets the full description of a mod, for showing it before it is added
+ */
+export async function getModDetails(modId: string, platform: ModPlatform): Promise<ModDetails> {
+  return await GetModDetails(modId, platform);
+}
+
+/**
+ * Adds a mod and its required dependencies to the current project
  */
 export async function addMod(
   modId: string,
   platform: ModPlatform,
   options: AddModOptions
-): Promise<void> {
-  await AddMod(modId, platform, options);
+): Promise<AddModResult> {
+  return await AddMod(modId, platform, options);
+}
+
+/**
+ * Formats the dependencies pulled in by addMod for display, along with the
+ * required ones that could not be added
+ */
+export function describeDependencies(result: AddModResult): string | undefined {
+  const all = [...(result.Dependencies ?? []), ...(result.Unresolved ?? [])];
+  const added = all.filter((d) => !d.Unresolved);
+  const unresolved = all.filter((d) => d.Unresolved);
+  const parts = [];
+  if (added.length > 0) {
+    parts.push('Also added: ' + added.map((d) => d.Name || d.ModID).join(', '));
+  }
+  if (unresolved.length > 0) {
+    parts.push('Add by hand: ' + unresolved.map((d) => `${d.Name || d.ModID} (${d.Unresolved})`).join(', '));
+  }
+  return parts.length > 0 ? parts.join('. ') : undefined;
+}
+
+/**
+ * Tells whether required dependencies were left out because no version of
+ * them could be found
+ */
+export function hasUnresolvedDependencies(deps: ModDependency[] | null | undefined): boolean {
+  return (deps ?? []).some((d) => d.Unresolved);
+}
+
+/**
+ * Explains that a version was matched through a fallback loader, such as a
+ * Fabric build used in a Quilt project
+ */
+export function describeFallbackLoader(loader?: string): string | undefined {
+  if (!loader) {
+    return undefined;
+  }
+  return `Uses the ${loader} build, which the project loader can run`;
 }
 
 export async function removeMod(
@@ -41,12 +101,26 @@ export async function removeMod(
     await RemoveMod(modId);
 }
 
-export async function checkModsUpdates(modIds: string[]): Promise<ModUpdateInfo[]> {
-    return await CheckModsUpdates(modIds);
+export async function checkModsUpdates(modIds: string[], forceRefresh = false): Promise<ModUpdateInfo[]> {
+    return await CheckModsUpdates(modIds, forceRefresh);
 }
 
-export async function updateMods(modsToUpdate: ModUpdateInfo[]): Promise<void> {
-    return await UpdateMods(modsToUpdate);
+/**
+ * Updates mods unless that would introduce conflicts, which are returned instead
+ */
+export async function updateMods(modsToUpdate: ModUpdateInfo[], ignoreConflicts = false): Promise<ModConflict[]> {
+    return (await UpdateMods(modsToUpdate, ignoreConflicts)) ?? [];
+}
+
+export async function checkCompatibility(): Promise<ModConflict[]> {
+    return (await CheckCompatibility()) ?? [];
+}
+
+/**
+ * Formats conflicts between mods for display
+ */
+export function describeConflicts(conflicts: ModConflict[]): string {
+  return conflicts.map((c) => `${c.ModID} ↔ ${c.ConflictsWith}`).join(', ');
 }
 
 export async function installMods(): Promise<void> {
@@ -57,16 +131,20 @@ export async function changeModSide(modId: string, side: ModSide): Promise<void>
     return await ChangeModSide(modId, side);
 }
 
+export async function changeModChannel(modId: string, channel: ReleaseChannel | ''): Promise<void> {
+    return await ChangeModChannel(modId, channel);
+}
+
 export async function changeModLocked(modId: string, locked: boolean): Promise<void> {
     return await ChangeModLocked(modId, locked);
 }
 
-export async function getModVersions(modId: string): Promise<string[]> {
-    return await GetModVersions(modId)
+export async function getModVersions(modId: string): Promise<ModVersion[]> {
+    return (await GetModVersions(modId)) ?? []
 }
 
-export async function changeModVersion(modId: string, version: string): Promise<void> {
-    return await ChangeModVersion(modId, version);
+export async function changeModVersion(modId: string, versionId: string): Promise<ModDependency[]> {
+    return (await ChangeModVersion(modId, versionId)) ?? [];
 }
 
 /**
@@ -103,3 +181,42 @@ export function requiresSideSelection(
   return platform === 'modrinth' && 
     (clientSide === 'optional' || serverSide === 'optional');
 }
+
+/**
+ * Opens a directory selection dialog and imports every jar in the selected
+ * folder. Returns null when the dialog was cancelled.
+ */
+export async function importModsFolder(): Promise<ImportReport | null> {
+  const folder = await SelectModsFolder();
+  if (!folder) return null;
+  return await ImportModsFolder(folder);
+}
+
+/**
+ * Summarises an import report for a toast description
+ */
+export function describeImport(report: ImportReport): string {
+  const parts = [`${report.Imported?.length ?? 0} identified`, `${report.Local?.length ?? 0} local`];
+  if (report.Skipped?.length) parts.push(`${report.Skipped.length} already in project`);
+  if (report.Failed?.length) parts.push(`${report.Failed.length} failed: ${report.Failed.map((f) => f.FileName).join(', ')}`);
+  if (report.Conflicts?.length) parts.push(`${report.Conflicts.length} not built for this project: ${report.Conflicts.map((f) => `${f.FileName} (${f.Error})`).join(', ')}`);
+  return parts.join(', ');
+}
+
+/**
+ * Opens a file selection dialog and adds the selected jar as a local mod.
+ * Returns the mod ID, or null when the dialog was cancelled.
+ */
+export async function addLocalMod(side: ModSide): Promise<string | null> {
+  const file = await SelectModFile();
+  if (!file) return null;
+  return await AddLocalMod(file, side);
+}
+
+/**
+ * Adds a mod downloaded from a direct link, returns its mod ID. When given,
+ * the downloaded jar must have the expected SHA-256 hash.
+ */
+export async function addURLMod(url: string, side: ModSide, expectedHash = ''): Promise<string> {
+  return await AddURLMod(url, expectedHash, side);
+}
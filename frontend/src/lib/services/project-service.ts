@@ -1,9 +1,15 @@
 import { 
   SelectProjectDirectory, 
   OpenProject, 
-  InitializeProject, GetLogs
+  InitializeProject, GetLogs,
+  ChangeProjectChannel,
+  ListMinecraftVersions,
+  ListLoaderVersions,
+  CheckProject,
+  RestoreProjectBackup
 } from '$backend';
-import type {LoaderType, ProjectConfig} from '$lib/types/project';
+import type {LoaderType, LoaderVersion, MinecraftVersion, ProjectConfig, ProjectState, ProjectStatus} from '$lib/types/project';
+import type {ReleaseChannel} from '$lib/types/mod';
 
 /**
  * Opens a directory selection dialog and returns the selected path
@@ -20,24 +26,64 @@ export async function getLogs(): Promise<string> {
  * Opens an existing project from the given path
  */
 export async function openProject(projectPath: string): Promise<ProjectConfig> {
-  const resp = await OpenProject(projectPath);
+  return toProjectConfig(await OpenProject(projectPath));
+}
+
+/**
+ * Tells why a project can or cannot be opened, without changing it
+ */
+export async function checkProject(projectPath: string): Promise<ProjectStatus> {
+  const resp = await CheckProject(projectPath);
+  return {
+    State: resp.State as ProjectState,
+    Error: resp.Error,
+    Backup: resp.Backup ?? null,
+  };
+}
+
+/**
+ * Replaces a corrupt project config with its newest backup and opens it
+ */
+export async function restoreProjectBackup(projectPath: string): Promise<ProjectConfig> {
+  return toProjectConfig(await RestoreProjectBackup(projectPath));
+}
 
+function toProjectConfig(resp: Awaited<ReturnType<typeof OpenProject>>): ProjectConfig {
     return {
         name: resp.name,
         minecraft: resp.minecraft,
         loader: resp.loader as LoaderType,
+        loaderVersion: resp.loaderVersion ?? '',
+        channel: (resp.channel || 'release') as ReleaseChannel,
         mods: resp.mods,
     };
 }
 
 /**
- * Initializes a new project at the given path
+ * Initializes a new project at the given path. An empty loader version
+ * picks the newest stable one.
  */
 export async function initializeProject(
   projectPath: string,
   name: string,
   minecraftVersion: string,
-  modLoader: string
+  modLoader: string,
+  loaderVersion = ''
 ): Promise<void> {
-  await InitializeProject(projectPath, name, minecraftVersion, modLoader);
+  await InitializeProject(projectPath, name, minecraftVersion, modLoader, loaderVersion);
+}
+
+export async function listMinecraftVersions(includeSnapshots = false): Promise<MinecraftVersion[]> {
+  return (await ListMinecraftVersions(includeSnapshots)) ?? [];
+}
+
+/**
+ * Lists the versions of a loader for a Minecraft version, newest first
+ */
+export async function listLoaderVersions(loader: LoaderType, minecraftVersion: string): Promise<LoaderVersion[]> {
+  return (await ListLoaderVersions(loader, minecraftVersion)) ?? [];
+}
+
+export async function changeProjectChannel(channel: ReleaseChannel): Promise<void> {
+  await ChangeProjectChannel(channel);
 }
@@ -0,0 +1,36 @@
+import {
+  GetSourceOptions,
+  ConfigureSources,
+  SetCurseForgeAPIKey,
+  ClearSourceCache,
+} from '$backend';
+import type {SourceOptions} from '$lib/types/settings';
+
+/**
+ * Gets the saved mod source settings
+ */
+export async function getSourceOptions(): Promise<SourceOptions> {
+  return await GetSourceOptions();
+}
+
+/**
+ * Saves and applies the mod source settings. An empty CurseForge API key
+ * keeps the saved one.
+ */
+export async function configureSources(options: SourceOptions): Promise<void> {
+  await ConfigureSources(options);
+}
+
+/**
+ * Saves the CurseForge API key, an empty key removes it
+ */
+export async function setCurseForgeAPIKey(apiKey: string): Promise<void> {
+  await SetCurseForgeAPIKey(apiKey);
+}
+
+/**
+ * Removes every cached platform response
+ */
+export async function clearSourceCache(): Promise<void> {
+  await ClearSourceCache();
+}
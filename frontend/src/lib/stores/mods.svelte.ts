@@ -1,4 +1,4 @@
-import type { ModSearchResult } from '$lib/types/mod';
+import type { ModSearchResult, SearchSort } from '$lib/types/mod';
 import * as modService from '$lib/services/mod-service';
 import type { ModPlatform } from '$lib/services/mod-service';
 import {toast} from "svelte-sonner";
@@ -8,6 +8,17 @@ class ModSearchStore {
   results = $state<ModSearchResult[]>([]);
   isSearching = $state(false);
   query = $state('');
+  page = $state(1);
+  pageSize = $state(10);
+  totalHits = $state(0);
+  sort = $state<SearchSort>('relevance');
+  categories = $state<string[]>([]);
+  clientSide = $state('');
+  serverSide = $state('');
+
+  get totalPages() {
+    return Math.max(1, Math.ceil(this.totalHits / this.pageSize));
+  }
   private _platform = $state<ModPlatform>('modrinth');
 
   get platform() {
@@ -18,13 +29,23 @@ class ModSearchStore {
     this._platform = value;
     }
 
-  search = async (searchQuery?: string) => {
+  search = async (searchQuery?: string, page = 1) => {
     const q = searchQuery ?? this.query;
     if (!q) return;
 
     this.isSearching = true;
     try {
-      this.results = await modService.searchMods(q, this._platform);
+      const result = await modService.searchMods(q, this._platform, {
+        Page: page,
+        PageSize: this.pageSize,
+        Sort: this.sort,
+        Categories: this.categories,
+        ClientSide: this.clientSide,
+        ServerSide: this.serverSide,
+      });
+      this.results = result.Hits ?? [];
+      this.totalHits = result.TotalHits;
+      this.page = result.Page;
     } catch (error) {
       console.error('Failed to search mods:', error);
       toast.error('Failed to search for mod', { description: String(error) });
@@ -34,8 +55,15 @@ class ModSearchStore {
     }
   }
 
+  goToPage = async (page: number) => {
+    if (page < 1 || page > this.totalPages) return;
+    await this.search(undefined, page);
+  }
+
   clearResults = async () => {
     this.results = [];
+    this.page = 1;
+    this.totalHits = 0;
   }
 }
 
@@ -1,12 +1,15 @@
 import type { Project } from '$lib/types/project';
 import { uiState } from './ui.svelte';
 import * as projectService from '$lib/services/project-service';
+import { toast } from 'svelte-sonner';
 
 const EMPTY_PROJECT: Project = {
   path: '',
   name: '',
   minecraft: '',
   loader: 'forge',
+  loaderVersion: '',
+  channel: 'release',
   mods: null,
 };
 
@@ -29,13 +32,51 @@ class ProjectStore {
                 path: projectPath,
                 ...projectConfig
             };
-        } catch {
+        } catch (error) {
+            await this.handleOpenError(projectPath, error);
+        }
+    };
+
+    // Only a missing packsmith.json starts a new project and only a corrupt
+    // one is offered for recovery, anything else must not be overwritten.
+    handleOpenError = async (projectPath: string, error: unknown) => {
+        console.error('Failed to open project:', error);
+        const status = await projectService.checkProject(projectPath).catch(() => null);
+        if (status?.State === 'uninitialized') {
             await uiState.openNewProjectDialog();
+            return;
+        }
+
+        const description = status?.Error || String(error);
+        if (status?.State !== 'corrupt' || !status.Backup) {
+            toast.error('Failed to open project', { description });
+            return;
+        }
+        toast.error('Failed to open project', {
+            description: `${description}. A backup from ${new Date(status.Backup.Created).toLocaleString()} is available.`,
+            duration: Infinity,
+            action: {
+                label: 'Restore backup',
+                onClick: () => this.restoreBackup(projectPath),
+            },
+        });
+    };
+
+    restoreBackup = async (projectPath: string) => {
+        try {
+            const projectConfig = await projectService.restoreProjectBackup(projectPath);
+            this.current = {
+                path: projectPath,
+                ...projectConfig
+            };
+            toast.success('Project restored from backup');
+        } catch (error) {
+            toast.error('Failed to restore backup', { description: String(error) });
         }
     };
 
-    createProject = async (name: string, minecraftVersion: string, modLoader: string) => {
-        await projectService.initializeProject(this.current.path, name, minecraftVersion, modLoader);
+    createProject = async (name: string, minecraftVersion: string, modLoader: string, loaderVersion = '') => {
+        await projectService.initializeProject(this.current.path, name, minecraftVersion, modLoader, loaderVersion);
         const projectConfig = await projectService.openProject(this.current.path);
         this.current = {
             path: this.current.path,
@@ -52,8 +93,8 @@ class ProjectStore {
                 path: this.current.path,
                 ...projectConfig
             };
-        } catch {
-            await uiState.openNewProjectDialog();
+        } catch (error) {
+            await this.handleOpenError(this.current.path, error);
         }
     }
 }
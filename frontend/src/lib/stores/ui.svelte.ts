@@ -1,25 +1,38 @@
 import type { ModSearchResult } from '$lib/types/mod';
 import { projectService } from '$lib/services';
+import type { ModPlatform } from '$lib/services/mod-service';
 
 // Dialog state management using Svelte 5 runes
 class UIStore {
   addModDialogOpen = $state(false);
+  addCustomModDialogOpen = $state(false);
   newProjectDialogOpen = $state(false);
   addModSideDialogOpen = $state(false);
   updateModsDialogOpen = $state(false);
   changeVersionModDialogOpen = $state(false);
   logsDialogOpen = $state(false);
+  settingsDialogOpen = $state(false);
+  modDetailsDialogOpen = $state(false);
   importModsDialogOpen = $state(false);
   changeModSideDialogOpen = $state(false);
   logsContent = $state('');
   modToAdd = $state<ModSearchResult | null>(null);
   selectedModId = $state<string | null>(null);
   modToChangeSide = $state<string | null>(null);
+  modToDescribe = $state<{ ID: string; Platform: ModPlatform } | null>(null);
 
   openAddModDialog = async () => {
     this.addModDialogOpen = true;
   }
 
+  openAddCustomModDialog = async () => {
+    this.addCustomModDialogOpen = true;
+  }
+
+  closeAddCustomModDialog = async () => {
+    this.addCustomModDialogOpen = false;
+  }
+
   openNewProjectDialog = async () => {
       this.newProjectDialogOpen = true;
   }
@@ -75,6 +88,24 @@ class UIStore {
     this.logsDialogOpen = false;
     this.logsContent = '';
   }
+
+  openSettingsDialog = async () => {
+    this.settingsDialogOpen = true;
+  }
+
+  closeSettingsDialog = async () => {
+    this.settingsDialogOpen = false;
+  }
+
+  openModDetailsDialog = async (modId: string, platform: ModPlatform) => {
+    this.modToDescribe = { ID: modId, Platform: platform };
+    this.modDetailsDialogOpen = true;
+  }
+
+  closeModDetailsDialog = async () => {
+    this.modDetailsDialogOpen = false;
+    this.modToDescribe = null;
+  }
 }
 
 export const uiState = new UIStore();
@@ -1,3 +1,4 @@
 // Type re-exports for cleaner imports
-export type { Mod, ModSide, ModSearchResult, AddModOptions } from './mod';
-export type { Project, ProjectConfig, LoaderType } from './project';
+export type { Mod, ModSide, ReleaseChannel, ModSearchResult, AddModOptions, AddModResult, ModDependency, ModConflict, SearchOptions, SearchPage, SearchSort, ModVersion, ImportedFile, ImportReport, ModDetails, GalleryImage } from './mod';
+export type { Project, ProjectConfig, LoaderType, MinecraftVersion, LoaderVersion, ProjectBackup, ProjectState, ProjectStatus } from './project';
+export type { SourceOptions } from './settings';
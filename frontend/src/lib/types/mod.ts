@@ -1,12 +1,35 @@
 export type ModSide = "client" | "server" | "both";
 
+export type ReleaseChannel = "release" | "beta" | "alpha";
+
 export interface Mod {
+  platform: string;
+  projectId: string;
   source: string;
   side: string;
   version: string;
+  versionId: string;
   url: string;
   filename: string;
   locked: boolean;
+  dependency: boolean;
+  channel: ReleaseChannel | "";
+  hash?: string;
+}
+
+export interface ModVersion {
+  ID: string;
+  ProjectID: string;
+  Version: string;
+  Channel: ReleaseChannel;
+  Published: string;
+  FileName: string;
+  FileSize: number;
+  GameVersions: string[] | null;
+  Loaders: string[] | null;
+  Changelog: string;
+  DownloadURL: string;
+  FallbackLoader: string;
 }
 
 export interface ModSearchResult {
@@ -17,17 +40,111 @@ export interface ModSearchResult {
   Downloads: string;
   ClientSide: string;
   ServerSide: string;
-  Versions: string[];
+  Versions: ModVersion[];
+}
+
+export interface GalleryImage {
+  URL: string;
+  Title: string;
+  Description: string;
+}
+
+export interface ModDetails {
+  ID: string;
+  ProjectID: string;
+  Platform: string;
+  Name: string;
+  Description: string;
+  IconURL: string;
+  Authors: string[] | null;
+  License: string;
+  LicenseURL: string;
+  Categories: string[] | null;
+  URL: string;
+  SourceURL: string;
+  IssuesURL: string;
+  WikiURL: string;
+  Downloads: number;
+  Followers: number;
+  Updated: string;
+  Gallery: GalleryImage[] | null;
+  Body: string;
+  BodyFormat: 'markdown' | 'html';
+}
+
+export interface ModDependency {
+  ModID: string;
+  ProjectID: string;
+  Name: string;
+  Version: string;
+  VersionID: string;
+  FileName: string;
+  URL: string;
+  Source: string;
+  RequiredBy: string;
+  Unresolved: string;
+}
+
+export interface ModConflict {
+  ModID: string;
+  ConflictsWith: string;
+  DeclaredBy: string;
+}
+
+export interface AddModResult {
+  ModID: string;
+  Added: boolean;
+  FallbackLoader: string;
+  Dependencies: ModDependency[] | null;
+  Unresolved?: ModDependency[] | null;
+  Conflicts: ModConflict[] | null;
+}
+
+export type SearchSort = 'relevance' | 'downloads' | 'follows' | 'newest' | 'updated';
+
+export interface SearchOptions {
+  Page: number;
+  PageSize: number;
+  Sort: SearchSort;
+  Categories: string[];
+  ClientSide: string;
+  ServerSide: string;
+}
+
+export interface SearchPage {
+  Hits: ModSearchResult[] | null;
+  TotalHits: number;
+  Page: number;
+  PageSize: number;
 }
 
 export interface AddModOptions {
   URL: string;
   Side: ModSide;
   Version: string;
+  VersionID: string;
+  IgnoreConflicts?: boolean;
 }
 
 export interface ModUpdateInfo {
     ModId: string;
     Version: string;
+    VersionID: string;
+    FileName: string;
     URL: string;
-}
\ No newline at end of file
+}
+export interface ImportedFile {
+  FileName: string;
+  ModID: string;
+  Platform: string;
+  Version: string;
+  Error: string;
+}
+
+export interface ImportReport {
+  Imported: ImportedFile[] | null;
+  Local: ImportedFile[] | null;
+  Skipped: ImportedFile[] | null;
+  Failed: ImportedFile[] | null;
+  Conflicts: ImportedFile[] | null;
+}
@@ -1,4 +1,4 @@
-import type { Mod } from './mod';
+import type { Mod, ReleaseChannel } from './mod';
 
 export type LoaderType = "forge" | "fabric" | "neoforge" | "quilt";
 
@@ -6,6 +6,8 @@ export interface ProjectConfig {
   name: string;
   minecraft: string;
   loader: LoaderType;
+  loaderVersion: string;
+  channel: ReleaseChannel;
   mods: Record<string, Mod>;
 }
 
@@ -14,5 +16,36 @@ export interface Project {
   name: string;
   minecraft: string;
   loader: LoaderType;
+  loaderVersion: string;
+  channel: ReleaseChannel;
   mods: Record<string, Mod> | null;
 }
+
+export interface MinecraftVersion {
+  ID: string;
+  Type: 'release' | 'snapshot';
+  Released: string;
+}
+
+export interface LoaderVersion {
+  Version: string;
+  Minecraft: string;
+  Stable: boolean;
+}
+
+export interface ProjectBackup {
+  Path: string;
+  Created: string;
+}
+
+export type ProjectState = 'ok' | 'uninitialized' | 'corrupt' | 'newer' | 'locked' | 'unreadable';
+
+/**
+ * Why a project can or cannot be opened. Backup is only set for corrupt
+ * projects, the only ones that can be restored from a backup.
+ */
+export interface ProjectStatus {
+  State: ProjectState;
+  Error: string;
+  Backup: ProjectBackup | null;
+}
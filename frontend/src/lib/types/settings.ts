@@ -0,0 +1,22 @@
+// Mirrors sources.Options. Empty fields use the public platform hosts.
+export interface SourceOptions {
+  ModrinthURL: string;
+  CurseForgeURL: string;
+  CurseForgeAPIURL: string;
+  CurseForgeAPIKey: string;
+  GitHubAPIURL: string;
+  GitHubToken: string;
+  MavenRepos: string[] | null;
+  UserAgent: string;
+  Proxy: string;
+  TimeoutSeconds: number;
+  CacheDir: string;
+  CacheTTLSeconds: number;
+  DisableCache: boolean;
+  MaxRetries: number | null;
+  MojangMetaURL: string;
+  FabricMetaURL: string;
+  QuiltMetaURL: string;
+  ForgeMavenURL: string;
+  NeoForgeMavenURL: string;
+}